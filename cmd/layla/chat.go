@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"git.jakub.app/jakub/X/cmd/layla/modules/ai"
+	"git.jakub.app/jakub/X/cmd/layla/modules/commands"
+	"git.jakub.app/jakub/X/cmd/layla/modules/discord"
+	"git.jakub.app/jakub/X/internal/env"
+	"git.jakub.app/jakub/X/internal/llm"
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	OPENAI_API_KEY = env.GetEnv("OPENAI_API_KEY", "")
+	LLM_MODEL      = env.GetEnv("LAYLA_LLM_MODEL", "gpt-4o-mini")
+
+	HISTORY_TOKENS    = env.GetEnvAsInt("LAYLA_HISTORY_TOKENS", 3000)
+	HISTORY_IDLE      = env.GetEnvAsDuration("LAYLA_HISTORY_IDLE", 2*time.Hour)
+	MAX_REPLY_TOKENS  = env.GetEnvAsInt("LAYLA_MAX_REPLY_TOKENS", 500)
+	USER_MESSAGES_MIN = env.GetEnvAsInt("LAYLA_USER_MESSAGES_PER_MINUTE", 6)
+	USER_BURST        = env.GetEnvAsInt("LAYLA_USER_BURST", 3)
+
+	// STREAM_REPLIES edits the reply as it is generated, at most once per
+	// STREAM_EDIT_INTERVAL to stay within the Discord rate limits.
+	STREAM_REPLIES       = env.GetEnvAsBool("LAYLA_STREAM_REPLIES", true)
+	STREAM_EDIT_INTERVAL = env.GetEnvAsDuration("LAYLA_STREAM_EDIT_INTERVAL", time.Second)
+)
+
+const chatTimeout = 2 * time.Minute
+
+// setupChat enables answering mentions and DMs, it is off without an
+// OpenAI API key.
+func (s *svc) setupChat(ctx context.Context) {
+	if OPENAI_API_KEY == "" {
+		log.Warn().Msg("OPENAI_API_KEY is not set, chat is disabled")
+		return
+	}
+
+	client := llm.NewClient(llm.NewOpenAIProvider(OPENAI_API_KEY, LLM_MODEL))
+	s.chat = ai.NewChat(client, ai.NewHistory(HISTORY_TOKENS, HISTORY_IDLE), s.chatTools(client), ai.Config{
+		MaxTokens:     MAX_REPLY_TOKENS,
+		Temperature:   0.8,
+		MaxToolRounds: MAX_TOOL_ROUNDS,
+	})
+	s.chatLimiter = ai.NewRateLimiter(USER_MESSAGES_MIN, USER_BURST)
+
+	s.router.Add(&commands.Command{
+		Name:        "reset",
+		Description: "Make layla forget the conversation",
+		AdminOnly:   true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "all",
+				Description: "Forget the conversations in every channel",
+			},
+		},
+		Handler: s.resetChat,
+	})
+
+	s.discordModule.Session().AddHandler(func(session *discordgo.Session, m *discordgo.MessageCreate) {
+		s.chatWG.Add(1)
+		defer s.chatWG.Done()
+		s.onMessage(ctx, session, m)
+	})
+}
+
+func (s *svc) onMessage(ctx context.Context, session *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.Author == nil || m.Author.Bot || ctx.Err() != nil {
+		return
+	}
+
+	self := session.State.User
+	text, addressed := addressedText(m, self)
+	if !addressed || text == "" {
+		return
+	}
+
+	logger := log.With().Str("userId", m.Author.ID).Str("channelId", m.ChannelID).Logger()
+
+	if ok, retryAfter := s.chatLimiter.Allow(m.Author.ID); !ok {
+		logger.Info().Dur("retryAfter", retryAfter).Msg("chat rate limited")
+		session.ChannelMessageSendReply(m.ChannelID, fmt.Sprintf("slow down a bit, try again in %s 🙏", retryAfter.Round(time.Second)), m.Reference())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, chatTimeout)
+	defer cancel()
+
+	session.ChannelTyping(m.ChannelID)
+
+	out := &replyWriter{
+		session:   session,
+		channelID: m.ChannelID,
+		reference: m.Reference(),
+		interval:  STREAM_EDIT_INTERVAL,
+	}
+	var progress func(string)
+	if STREAM_REPLIES {
+		progress = func(partial string) {
+			if err := out.update(partial, false); err != nil {
+				logger.Error().Err(err).Msg("can't update chat reply")
+			}
+		}
+	}
+
+	reply, err := s.chat.Reply(ctx, m.ChannelID, m.Author.Username, text, progress)
+	if err != nil {
+		logger.Error().Err(err).Msg("can't complete chat reply")
+		session.ChannelMessageSendReply(m.ChannelID, "oops, something went wrong on my side 😵", m.Reference())
+		return
+	}
+
+	if err := out.update(reply, true); err != nil {
+		logger.Error().Err(err).Msg("can't send chat reply")
+	}
+}
+
+// replyWriter shows a reply that grows while it is streamed, sending more
+// messages once it doesn't fit in one and editing the last ones.
+type replyWriter struct {
+	session   *discordgo.Session
+	channelID string
+	reference *discordgo.MessageReference
+	interval  time.Duration
+
+	messages []*discordgo.Message
+	lastEdit time.Time
+}
+
+// update shows text, partial updates are skipped until interval passed since
+// the previous one.
+func (w *replyWriter) update(text string, final bool) error {
+	text = strings.TrimSpace(text)
+	if text == "" || (!final && time.Since(w.lastEdit) < w.interval) {
+		return nil
+	}
+	w.lastEdit = time.Now()
+
+	parts := splitMessage(text, discord.MessageContentLimit)
+	for i, part := range parts {
+		if i < len(w.messages) {
+			if w.messages[i].Content == part {
+				continue
+			}
+			msg, err := w.session.ChannelMessageEdit(w.channelID, w.messages[i].ID, part)
+			if err != nil {
+				return err
+			}
+			w.messages[i] = msg
+			continue
+		}
+
+		var (
+			msg *discordgo.Message
+			err error
+		)
+		if i == 0 {
+			msg, err = w.session.ChannelMessageSendReply(w.channelID, part, w.reference)
+		} else {
+			msg, err = w.session.ChannelMessageSend(w.channelID, part)
+		}
+		if err != nil {
+			return err
+		}
+		w.messages = append(w.messages, msg)
+	}
+
+	// A partial reply can be split at other places than the final one.
+	for len(w.messages) > len(parts) {
+		last := w.messages[len(w.messages)-1]
+		if err := w.session.ChannelMessageDelete(w.channelID, last.ID); err != nil {
+			return err
+		}
+		w.messages = w.messages[:len(w.messages)-1]
+	}
+	return nil
+}
+
+// addressedText returns the message without the bot mention and whether it
+// is meant for the bot, i.e. a DM or a mention.
+func addressedText(m *discordgo.MessageCreate, self *discordgo.User) (string, bool) {
+	addressed := m.GuildID == ""
+	for _, user := range m.Mentions {
+		if user.ID == self.ID {
+			addressed = true
+		}
+	}
+
+	text := strings.NewReplacer("<@"+self.ID+">", "", "<@!"+self.ID+">", "").Replace(m.Content)
+	return strings.TrimSpace(text), addressed
+}
+
+// splitMessage cuts text into parts of at most limit characters, preferring
+// line breaks.
+func splitMessage(text string, limit int) []string {
+	var parts []string
+	runes := []rune(text)
+	for len(runes) > limit {
+		cut := limit
+		for i := limit - 1; i > limit/2; i-- {
+			if runes[i] == '\n' {
+				cut = i
+				break
+			}
+		}
+		parts = append(parts, string(runes[:cut]))
+		runes = []rune(strings.TrimLeft(string(runes[cut:]), "\n"))
+	}
+	if len(runes) > 0 {
+		parts = append(parts, string(runes))
+	}
+	return parts
+}
+
+func (s *svc) resetChat(c *commands.Context) error {
+	if c.Bool("all", false) {
+		s.chat.History().ResetAll()
+		return c.ReplyEphemeral("forgot every conversation 🧹")
+	}
+
+	s.chat.History().Reset(c.Interaction.ChannelID)
+	return c.ReplyEphemeral("forgot the conversation in this channel 🧹")
+}
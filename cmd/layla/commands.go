@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"git.jakub.app/jakub/X/cmd/layla/modules/commands"
+)
+
+func (s *svc) commands() []*commands.Command {
+	return []*commands.Command{
+		{
+			Name:        "ping",
+			Description: "Check that layla is alive",
+			Handler:     s.ping,
+		},
+		{
+			Name:        "help",
+			Description: "List the available commands",
+			Handler:     s.help,
+		},
+	}
+}
+
+func (s *svc) ping(c *commands.Context) error {
+	latency := c.Session.HeartbeatLatency().Round(time.Millisecond)
+	return c.ReplyEphemeral(fmt.Sprintf("🏓 Pong! Gateway latency: %s", latency))
+}
+
+func (s *svc) help(c *commands.Context) error {
+	var b strings.Builder
+	for _, cmd := range s.router.Commands() {
+		if cmd.AdminOnly && !s.router.IsAdmin(c.User().ID) {
+			continue
+		}
+		if len(cmd.Subcommands) == 0 {
+			fmt.Fprintf(&b, "`/%s` — %s\n", cmd.Name, cmd.Description)
+			continue
+		}
+		for _, sub := range cmd.Subcommands {
+			fmt.Fprintf(&b, "`/%s %s` — %s\n", cmd.Name, sub.Name, sub.Description)
+		}
+	}
+	return c.ReplyEphemeral(b.String())
+}
@@ -1,17 +1,50 @@
 package main
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"git.jakub.app/jakub/X/cmd/layla/modules/ai"
+	"git.jakub.app/jakub/X/cmd/layla/modules/commands"
 	"git.jakub.app/jakub/X/cmd/layla/modules/discord"
+	"git.jakub.app/jakub/X/internal/env"
+	"git.jakub.app/jakub/X/internal/kuchniaviking"
+	"github.com/bwmarrin/discordgo"
 	"github.com/rs/zerolog/log"
 )
 
+var (
+	// LAYLA_GUILD_ID registers the commands in a single guild, where changes
+	// show up immediately. Empty registers them globally.
+	LAYLA_GUILD_ID   = env.GetEnv("LAYLA_GUILD_ID", "")
+	LAYLA_ADMIN_IDS  = env.GetEnvAsSlice("LAYLA_ADMIN_IDS", nil, ",")
+	SHUTDOWN_TIMEOUT = env.GetEnvAsDuration("LAYLA_SHUTDOWN_TIMEOUT", 30*time.Second)
+)
+
 type svc struct {
 	discordModule *discord.Discord
+	router        *commands.Router
+
+	kvMu sync.Mutex
+	kv   kuchniaviking.KuchniaVikinga
+
+	// interactions handles prompts waiting for buttons, modals and
+	// reactions.
+	interactions *discord.Interactions
+
+	chat        *ai.Chat
+	chatLimiter *ai.RateLimiter
+	// chatWG tracks chat replies in progress for the shutdown.
+	chatWG sync.WaitGroup
 }
 
-func run() error {
+func run(ctx context.Context) error {
 	var err error
-	svc := svc{}
+	svc := &svc{}
 
 	svc.discordModule, err = discord.New()
 	if err != nil {
@@ -19,11 +52,57 @@ func run() error {
 		return err
 	}
 
-	return nil
+	svc.interactions = discord.NewInteractions(svc.discordModule)
+	svc.router = commands.NewRouter(LAYLA_ADMIN_IDS)
+	svc.router.Add(svc.commands()...)
+	svc.router.Add(svc.menuCommands()...)
+	// Answers to prompts go through the router like any other component.
+	svc.router.AddComponent(discord.WaitPrefix, func(c *commands.Context) error {
+		svc.interactions.Deliver(c.Session, c.Interaction)
+		return nil
+	})
+	svc.menuComponents()
+	svc.setupChat(ctx)
+
+	session := svc.discordModule.Session()
+	session.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMessages | discordgo.IntentsDirectMessages |
+		discordgo.IntentsGuildMessageReactions | discordgo.IntentsDirectMessageReactions
+	session.AddHandler(svc.router.Handle)
+	session.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
+		log.Info().Str("user", r.User.Username).Int("guilds", len(r.Guilds)).Msg("layla is ready")
+	})
+
+	if err := svc.discordModule.Open(ctx); err != nil {
+		return err
+	}
+
+	if err := svc.router.Register(session, LAYLA_GUILD_ID); err != nil {
+		log.Error().Err(err).Msg("can't register commands")
+		svc.discordModule.Close()
+		return err
+	}
+	log.Info().Str("guildId", LAYLA_GUILD_ID).Msg("commands registered")
+
+	<-ctx.Done()
+	log.Info().Msg("shutting down, waiting for running commands")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), SHUTDOWN_TIMEOUT)
+	defer cancel()
+	svc.router.Close(shutdownCtx)
+	svc.interactions.Close()
+
+	// Closing the gateway stops new messages, replies still go through the
+	// REST API.
+	err = svc.discordModule.Close()
+	svc.chatWG.Wait()
+	return err
 }
 
 func main() {
-	if err := run(); err != nil {
-		panic(err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx); err != nil {
+		log.Fatal().Err(err).Msg("layla failed")
 	}
 }
@@ -0,0 +1,526 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"git.jakub.app/jakub/X/cmd/layla/modules/commands"
+	"git.jakub.app/jakub/X/cmd/layla/modules/discord"
+	"git.jakub.app/jakub/X/internal/cache"
+	"git.jakub.app/jakub/X/internal/kuchniaviking"
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	dateLayout      = "2006-01-02"
+	maxAllergenDays = 14
+)
+
+type dayMenu struct {
+	Date time.Time
+	// Delivery is nil when nothing is delivered that day.
+	Delivery *kuchniaviking.Delivery
+	Meals    []kuchniaviking.DeliveryMenuItem
+	Err      error
+}
+
+func (s *svc) kuchniaViking() (kuchniaviking.KuchniaVikinga, error) {
+	s.kvMu.Lock()
+	defer s.kvMu.Unlock()
+
+	if s.kv != nil {
+		return s.kv, nil
+	}
+
+	kv, err := kuchniaviking.New()
+	if err != nil {
+		return nil, err
+	}
+
+	s.kv = kuchniaviking.NewCached(kv, cache.NewMemory(), kuchniaviking.DefaultCacheTTL())
+	return s.kv, nil
+}
+
+// menus returns the menu of every day from from on. A menu that can't be
+// fetched is returned with Err set instead of failing the whole range.
+func (s *svc) menus(from time.Time, days int) ([]dayMenu, error) {
+	kv, err := s.kuchniaViking()
+	if err != nil {
+		return nil, fmt.Errorf("can't initialize kuchnia vikinga: %w", err)
+	}
+
+	ids, err := kv.GetActiveIds()
+	if err != nil {
+		return nil, fmt.Errorf("can't get active ids: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, commands.Errorf("You don't have an active order.")
+	}
+
+	orderData, err := kv.GetOrderData(ids[0])
+	if err != nil {
+		return nil, fmt.Errorf("can't get order data: %w", err)
+	}
+
+	deliveries, err := kv.GetDeliveries(orderData.Deliveries, kuchniaviking.DeliveryQuery{
+		From:        from,
+		To:          from.AddDate(0, 0, days-1),
+		IncludePast: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't get deliveries: %w", err)
+	}
+
+	byDate := make(map[string]kuchniaviking.Delivery)
+	for _, delivery := range deliveries {
+		if !delivery.Deleted {
+			byDate[delivery.Date] = delivery
+		}
+	}
+
+	menus := make([]dayMenu, days)
+	for i := range menus {
+		date := from.AddDate(0, 0, i)
+		menus[i].Date = date
+
+		delivery, ok := byDate[date.Format(dateLayout)]
+		if !ok {
+			continue
+		}
+		menus[i].Delivery = &delivery
+
+		info, err := kv.GetDeliveryInfo(delivery.DeliveryID)
+		if err != nil {
+			log.Error().Err(err).Int("deliveryId", delivery.DeliveryID).Msg("can't get delivery info")
+			menus[i].Err = err
+			continue
+		}
+
+		meals := info.DeliveryMenuMeal
+		sort.SliceStable(meals, func(a, b int) bool {
+			return meals[a].MealPriority < meals[b].MealPriority
+		})
+		menus[i].Meals = meals
+	}
+	return menus, nil
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// weekStart returns the Monday of the week t is in.
+func weekStart(t time.Time) time.Time {
+	return t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+}
+
+func (s *svc) menuCommands() []*commands.Command {
+	return []*commands.Command{
+		{
+			Name:        "menu",
+			Description: "Show the Kuchnia Vikinga menu",
+			Subcommands: []*commands.Command{
+				{
+					Name:        "today",
+					Description: "Today's menu",
+					Handler:     s.menuDay(0),
+				},
+				{
+					Name:        "tomorrow",
+					Description: "Tomorrow's menu",
+					Handler:     s.menuDay(1),
+				},
+				{
+					Name:        "week",
+					Description: "This week's menu",
+					Handler:     s.menuWeek,
+				},
+			},
+		},
+		{
+			Name:        "allergens",
+			Description: "List upcoming meals containing allergens",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "allergens",
+					Description: "Comma separated allergens, defaults to the configured profile",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "days",
+					Description: "How many days ahead to check, 7 by default",
+					MinValue:    ptr(1.0),
+					MaxValue:    maxAllergenDays,
+				},
+			},
+			Handler: s.allergens,
+		},
+		{
+			Name:        "nutrition",
+			Description: "Show calories and macros",
+			Subcommands: []*commands.Command{
+				{
+					Name:        "week",
+					Description: "Daily calories and macros of this week",
+					Handler:     s.nutritionWeek,
+				},
+			},
+		},
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// Buttons use custom IDs like "menu:day:2024-01-02", "menu:week:..." and
+// "nutrition:week:...". "today" stands for the current day or week.
+func (s *svc) menuComponents() {
+	s.router.AddComponent("menu", s.menuPage)
+	s.router.AddComponent("nutrition", s.nutritionPage)
+}
+
+func (s *svc) menuDay(offset int) commands.Handler {
+	return func(c *commands.Context) error {
+		if err := c.Defer(false); err != nil {
+			return err
+		}
+		data, err := s.renderDay(today().AddDate(0, 0, offset))
+		if err != nil {
+			return err
+		}
+		return c.Respond(data)
+	}
+}
+
+func (s *svc) menuWeek(c *commands.Context) error {
+	if err := c.Defer(false); err != nil {
+		return err
+	}
+	data, err := s.renderWeek(weekStart(today()))
+	if err != nil {
+		return err
+	}
+	return c.Respond(data)
+}
+
+func (s *svc) nutritionWeek(c *commands.Context) error {
+	if err := c.Defer(false); err != nil {
+		return err
+	}
+	data, err := s.renderNutrition(weekStart(today()))
+	if err != nil {
+		return err
+	}
+	return c.Respond(data)
+}
+
+func (s *svc) menuPage(c *commands.Context) error {
+	view, date, err := pageArgs(c)
+	if err != nil {
+		return err
+	}
+	if err := c.DeferUpdate(); err != nil {
+		return err
+	}
+
+	var data *discordgo.InteractionResponseData
+	switch view {
+	case "day":
+		data, err = s.renderDay(date)
+	case "week":
+		if c.ComponentArgs()[1] == "today" {
+			date = weekStart(date)
+		}
+		data, err = s.renderWeek(date)
+	default:
+		return fmt.Errorf("unknown menu view %q", view)
+	}
+	if err != nil {
+		return err
+	}
+	return c.Update(data)
+}
+
+func (s *svc) nutritionPage(c *commands.Context) error {
+	_, date, err := pageArgs(c)
+	if err != nil {
+		return err
+	}
+	if c.ComponentArgs()[1] == "today" {
+		date = weekStart(date)
+	}
+	if err := c.DeferUpdate(); err != nil {
+		return err
+	}
+
+	data, err := s.renderNutrition(date)
+	if err != nil {
+		return err
+	}
+	return c.Update(data)
+}
+
+func pageArgs(c *commands.Context) (string, time.Time, error) {
+	args := c.ComponentArgs()
+	if len(args) != 2 {
+		return "", time.Time{}, fmt.Errorf("invalid custom id %q", c.CustomID())
+	}
+	if args[1] == "today" {
+		return args[0], today(), nil
+	}
+
+	date, err := time.Parse(dateLayout, args[1])
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid custom id %q: %w", c.CustomID(), err)
+	}
+	return args[0], date, nil
+}
+
+// pager returns prev, current and next buttons for the view starting at
+// date.
+func pager(prefix, view string, date time.Time, step int) ([]discordgo.MessageComponent, error) {
+	buttons := []struct {
+		label string
+		style discordgo.ButtonStyle
+		date  string
+	}{
+		{"◀", discordgo.SecondaryButton, date.AddDate(0, 0, -step).Format(dateLayout)},
+		{"Today", discordgo.PrimaryButton, "today"},
+		{"▶", discordgo.SecondaryButton, date.AddDate(0, 0, step).Format(dateLayout)},
+	}
+
+	row := discordgo.ActionsRow{}
+	for _, b := range buttons {
+		customID, err := discord.EncodeCustomID(prefix, view, b.date)
+		if err != nil {
+			return nil, err
+		}
+		row.Components = append(row.Components, discordgo.Button{
+			Label:    b.label,
+			Style:    b.style,
+			CustomID: customID,
+		})
+	}
+	return []discordgo.MessageComponent{row}, nil
+}
+
+func (s *svc) renderDay(date time.Time) (*discordgo.InteractionResponseData, error) {
+	menus, err := s.menus(date, 1)
+	if err != nil {
+		return nil, err
+	}
+	day := menus[0]
+	profile := kuchniaviking.DefaultAllergenProfile()
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("🍽️ %s", date.Format("Monday, 2006-01-02")),
+		Color: 0x2ECC71,
+	}
+	switch {
+	case day.Delivery == nil:
+		embed.Description = "No delivery this day."
+	case day.Err != nil:
+		embed.Description = "The menu is not available right now."
+	default:
+		var total kuchniaviking.Nutrition
+		for _, meal := range day.Meals {
+			total = addNutrition(total, meal.Nutrition)
+
+			value := fmt.Sprintf("**%s**\n%s", meal.MenuMealName, meal.Nutrition.Macros())
+			for _, match := range profile.Evaluate(meal) {
+				value += fmt.Sprintf("\n⚠️ %s (%s)", match.Ingredient, match.Allergen)
+			}
+			embed.Fields = append(embed.Fields, field(meal.MealName, value))
+		}
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "Total: " + total.Macros()}
+	}
+
+	components, err := pager("menu", "day", date, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &discordgo.InteractionResponseData{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	}, nil
+}
+
+func (s *svc) renderWeek(from time.Time) (*discordgo.InteractionResponseData, error) {
+	menus, err := s.menus(from, 7)
+	if err != nil {
+		return nil, err
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("🗓️ Menu %s – %s", from.Format("02.01"), from.AddDate(0, 0, 6).Format("02.01.2006")),
+		Color: 0x2ECC71,
+	}
+	for _, day := range menus {
+		value, ok := missingMenu(day)
+		if ok {
+			var lines []string
+			for _, meal := range day.Meals {
+				lines = append(lines, fmt.Sprintf("%s: %s", meal.MealName, meal.MenuMealName))
+			}
+			value = strings.Join(lines, "\n")
+		}
+		embed.Fields = append(embed.Fields, field(day.Date.Format("Monday 02.01"), value))
+	}
+
+	components, err := pager("menu", "week", from, 7)
+	if err != nil {
+		return nil, err
+	}
+	return &discordgo.InteractionResponseData{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	}, nil
+}
+
+func (s *svc) renderNutrition(from time.Time) (*discordgo.InteractionResponseData, error) {
+	menus, err := s.menus(from, 7)
+	if err != nil {
+		return nil, err
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("📊 Nutrition %s – %s", from.Format("02.01"), from.AddDate(0, 0, 6).Format("02.01.2006")),
+		Color: 0x3498DB,
+	}
+
+	var (
+		total kuchniaviking.Nutrition
+		days  int
+	)
+	for _, day := range menus {
+		var dayTotal kuchniaviking.Nutrition
+		for _, meal := range day.Meals {
+			dayTotal = addNutrition(dayTotal, meal.Nutrition)
+		}
+		if len(day.Meals) > 0 {
+			total = addNutrition(total, dayTotal)
+			days++
+		}
+
+		value, ok := missingMenu(day)
+		if ok && len(day.Meals) > 0 {
+			value = dayTotal.Macros()
+		}
+		embed.Fields = append(embed.Fields, field(day.Date.Format("Monday 02.01"), value))
+	}
+	if days > 0 {
+		average := kuchniaviking.Nutrition{
+			Calories:     total.Calories / float64(days),
+			Protein:      total.Protein / float64(days),
+			Fat:          total.Fat / float64(days),
+			Carbohydrate: total.Carbohydrate / float64(days),
+		}
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "Daily average: " + average.Macros()}
+	}
+
+	components, err := pager("nutrition", "week", from, 7)
+	if err != nil {
+		return nil, err
+	}
+	return &discordgo.InteractionResponseData{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	}, nil
+}
+
+func (s *svc) allergens(c *commands.Context) error {
+	if err := c.Defer(false); err != nil {
+		return err
+	}
+
+	profile := kuchniaviking.DefaultAllergenProfile()
+	if v := c.String("allergens", ""); v != "" {
+		profile = kuchniaviking.NewAllergenProfile(strings.Split(v, ","))
+	}
+	if len(profile.Allergens) == 0 {
+		return commands.Errorf("No allergens to check.")
+	}
+
+	days := int(c.Int("days", 7))
+	menus, err := s.menus(today(), days)
+	if err != nil {
+		return err
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:  fmt.Sprintf("⚠️ Allergens in the next %d days", days),
+		Color:  0xFF0000,
+		Footer: &discordgo.MessageEmbedFooter{Text: "Checked: " + strings.Join(profile.Allergens, ", ")},
+	}
+	var unavailable []string
+	for _, day := range menus {
+		if day.Err != nil {
+			unavailable = append(unavailable, day.Date.Format(dateLayout))
+			continue
+		}
+		for _, meal := range profile.FilterMeals(day.Meals) {
+			var lines []string
+			for _, match := range meal.Matches {
+				lines = append(lines, fmt.Sprintf("%s (%s)", match.Ingredient, match.Allergen))
+			}
+			embed.Fields = append(embed.Fields, field(
+				fmt.Sprintf("%s · %s", day.Date.Format("Mon 02.01"), meal.MealName),
+				fmt.Sprintf("**%s**\n%s", meal.MenuMealName, strings.Join(lines, "\n")),
+			))
+		}
+	}
+
+	if len(embed.Fields) == 0 {
+		embed.Description = "No meals with allergens found. 🎉"
+		embed.Color = 0x2ECC71
+	}
+	if len(embed.Fields) > discord.EmbedFieldsLimit {
+		embed.Description = fmt.Sprintf("Showing %d of %d meals.", discord.EmbedFieldsLimit, len(embed.Fields))
+		embed.Fields = embed.Fields[:discord.EmbedFieldsLimit]
+	}
+	if len(unavailable) > 0 {
+		embed.Description = strings.TrimSpace(embed.Description + "\nMenu not available for " + strings.Join(unavailable, ", ") + ".")
+	}
+
+	return c.ReplyEmbeds(embed)
+}
+
+// missingMenu returns a note and false when day has no menu to show.
+func missingMenu(day dayMenu) (string, bool) {
+	switch {
+	case day.Delivery == nil:
+		return "No delivery", false
+	case day.Err != nil:
+		return "Menu not available", false
+	}
+	return "", true
+}
+
+// field builds an embed field. Discord rejects the whole embed when a field
+// name or value is empty.
+func field(name, value string) *discordgo.MessageEmbedField {
+	if name == "" {
+		name = "\u200b"
+	}
+	if value == "" {
+		value = "No meals"
+	}
+	return &discordgo.MessageEmbedField{
+		Name:  discord.Truncate(name, discord.EmbedFieldNameLimit),
+		Value: discord.Truncate(value, discord.EmbedFieldValueLimit),
+	}
+}
+
+func addNutrition(a, b kuchniaviking.Nutrition) kuchniaviking.Nutrition {
+	a.Calories += b.Calories
+	a.Protein += b.Protein
+	a.Fat += b.Fat
+	a.Carbohydrate += b.Carbohydrate
+	return a
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"git.jakub.app/jakub/X/cmd/layla/modules/discord"
+)
+
+func TestField(t *testing.T) {
+	tests := []struct {
+		name, value         string
+		wantName, wantValue string
+	}{
+		{"Monday 01.01", "Obiad: zupa", "Monday 01.01", "Obiad: zupa"},
+		{"Monday 01.01", "", "Monday 01.01", "No meals"},
+		{"", "Obiad: zupa", "\u200b", "Obiad: zupa"},
+	}
+	for _, tt := range tests {
+		f := field(tt.name, tt.value)
+		if f.Name != tt.wantName || f.Value != tt.wantValue {
+			t.Errorf("field(%q, %q) = %q, %q; want %q, %q", tt.name, tt.value, f.Name, f.Value, tt.wantName, tt.wantValue)
+		}
+	}
+
+	long := field(strings.Repeat("n", 300), strings.Repeat("v", 2000))
+	if len([]rune(long.Name)) != discord.EmbedFieldNameLimit || len([]rune(long.Value)) != discord.EmbedFieldValueLimit {
+		t.Errorf("field() kept %d and %d characters, want them truncated to the limits", len([]rune(long.Name)), len([]rune(long.Value)))
+	}
+}
@@ -0,0 +1,117 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"git.jakub.app/jakub/X/internal/llm"
+)
+
+type Config struct {
+	MaxTokens   int
+	Temperature float32
+	// MaxToolRounds limits the tool calls per reply, see
+	// llm.DefaultMaxToolRounds.
+	MaxToolRounds int
+}
+
+// Chat answers messages in the Layla persona, remembering the conversation
+// per channel.
+type Chat struct {
+	client  *llm.Client
+	history *History
+	// tools may be nil, the chat then answers without tools.
+	tools  *Registry
+	config Config
+}
+
+func NewChat(client *llm.Client, history *History, tools *Registry, config Config) *Chat {
+	return &Chat{
+		client:  client,
+		history: history,
+		tools:   tools,
+		config:  config,
+	}
+}
+
+func (c *Chat) History() *History {
+	return c.history
+}
+
+// Reply answers text written by author in channelID. The exchange is added
+// to the channel history only when the completion succeeds, tool calls made
+// on the way are not remembered. With progress set the reply is streamed and
+// progress is called with the text generated so far as it grows.
+func (c *Chat) Reply(ctx context.Context, channelID, author, text string, progress func(partial string)) (string, error) {
+	user := llm.Message{Role: llm.RoleUser, Content: fmt.Sprintf("%s: %s", author, text)}
+
+	messages := []llm.Message{{Role: llm.RoleSystem, Content: SystemPrompt()}}
+	messages = append(messages, c.history.Messages(channelID)...)
+	messages = append(messages, user)
+
+	req := llm.CompletionRequest{
+		Messages:    messages,
+		MaxTokens:   c.config.MaxTokens,
+		Temperature: c.config.Temperature,
+	}
+	withTools := c.tools != nil && len(c.tools.Tools()) > 0
+
+	var (
+		resp llm.CompletionResponse
+		err  error
+	)
+	switch {
+	case progress != nil:
+		resp, err = c.stream(ctx, req, withTools, progress)
+	case withTools:
+		resp, _, err = c.client.CompleteWithTools(ctx, req, c.tools, c.config.MaxToolRounds)
+	default:
+		resp, err = c.client.Complete(ctx, req)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	reply := strings.TrimSpace(resp.Content)
+	c.history.Append(channelID, user, llm.Message{Role: llm.RoleAssistant, Content: reply})
+	return reply, nil
+}
+
+func (c *Chat) stream(ctx context.Context, req llm.CompletionRequest, withTools bool, progress func(string)) (llm.CompletionResponse, error) {
+	// Cancelling stops the stream when it isn't read to the end.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var chunks <-chan llm.Chunk
+	if withTools {
+		chunks = c.client.StreamWithTools(ctx, req, c.tools, c.config.MaxToolRounds)
+	} else {
+		var err error
+		if chunks, err = c.client.Stream(ctx, req); err != nil {
+			return llm.CompletionResponse{}, err
+		}
+	}
+
+	var (
+		resp    llm.CompletionResponse
+		content strings.Builder
+	)
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			return resp, chunk.Err
+		}
+		if chunk.Content != "" {
+			content.WriteString(chunk.Content)
+			progress(content.String())
+		}
+		if chunk.FinishReason != "" {
+			resp.FinishReason = chunk.FinishReason
+		}
+		if chunk.Usage != nil {
+			resp.Usage = *chunk.Usage
+		}
+	}
+	resp.Content = content.String()
+	return resp, nil
+}
@@ -0,0 +1,103 @@
+package ai
+
+import (
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"git.jakub.app/jakub/X/internal/llm"
+)
+
+// messageOverhead approximates the tokens every message costs besides its
+// content.
+const messageOverhead = 4
+
+// EstimateTokens approximates the token count of s. There is no tokenizer
+// here, about four characters per token is close enough for budgeting.
+func EstimateTokens(s string) int {
+	return (utf8.RuneCountInString(s)+3)/4 + messageOverhead
+}
+
+type conversation struct {
+	messages []llm.Message
+	tokens   []int
+	total    int
+	lastUsed time.Time
+}
+
+// History keeps the conversation of every channel within a token budget,
+// dropping the oldest messages first. Conversations idle for longer than
+// idleTimeout start over.
+type History struct {
+	mu          sync.Mutex
+	channels    map[string]*conversation
+	budget      int
+	idleTimeout time.Duration
+}
+
+func NewHistory(budget int, idleTimeout time.Duration) *History {
+	return &History{
+		channels:    make(map[string]*conversation),
+		budget:      budget,
+		idleTimeout: idleTimeout,
+	}
+}
+
+// Messages returns a copy of the channel's conversation.
+func (h *History) Messages(channelID string) []llm.Message {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c := h.conversation(channelID)
+	return append([]llm.Message(nil), c.messages...)
+}
+
+// Append adds messages to the channel's conversation and trims it to the
+// budget.
+func (h *History) Append(channelID string, messages ...llm.Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c := h.conversation(channelID)
+	for _, msg := range messages {
+		tokens := EstimateTokens(msg.Content)
+		c.messages = append(c.messages, msg)
+		c.tokens = append(c.tokens, tokens)
+		c.total += tokens
+	}
+	c.lastUsed = time.Now()
+
+	for len(c.messages) > 0 && c.total > h.budget {
+		c.total -= c.tokens[0]
+		c.messages = c.messages[1:]
+		c.tokens = c.tokens[1:]
+	}
+	// Never start with an assistant message, the model would lose the
+	// question it answers.
+	for len(c.messages) > 0 && c.messages[0].Role == llm.RoleAssistant {
+		c.total -= c.tokens[0]
+		c.messages = c.messages[1:]
+		c.tokens = c.tokens[1:]
+	}
+}
+
+func (h *History) Reset(channelID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.channels, channelID)
+}
+
+func (h *History) ResetAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.channels = make(map[string]*conversation)
+}
+
+func (h *History) conversation(channelID string) *conversation {
+	c, ok := h.channels[channelID]
+	if !ok || (h.idleTimeout > 0 && time.Since(c.lastUsed) > h.idleTimeout) {
+		c = &conversation{lastUsed: time.Now()}
+		h.channels[channelID] = c
+	}
+	return c
+}
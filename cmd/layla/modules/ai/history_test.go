@@ -0,0 +1,118 @@
+package ai
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"git.jakub.app/jakub/X/internal/llm"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"", messageOverhead},
+		{"abc", 1 + messageOverhead},
+		{"abcd", 1 + messageOverhead},
+		{"abcde", 2 + messageOverhead},
+		// Runes, not bytes.
+		{"żółw", 1 + messageOverhead},
+	}
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.s); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestHistoryTrimming(t *testing.T) {
+	user := func(content string) llm.Message {
+		return llm.Message{Role: llm.RoleUser, Content: content}
+	}
+	assistant := func(content string) llm.Message {
+		return llm.Message{Role: llm.RoleAssistant, Content: content}
+	}
+	// Every message of four characters costs 1 + messageOverhead tokens.
+	const cost = 1 + messageOverhead
+
+	tests := []struct {
+		name    string
+		budget  int
+		appends [][]llm.Message
+		want    []llm.Message
+	}{
+		{
+			name:    "within budget",
+			budget:  4 * cost,
+			appends: [][]llm.Message{{user("q1.."), assistant("a1..")}, {user("q2.."), assistant("a2..")}},
+			want:    []llm.Message{user("q1.."), assistant("a1.."), user("q2.."), assistant("a2..")},
+		},
+		{
+			name:    "oldest dropped first",
+			budget:  2 * cost,
+			appends: [][]llm.Message{{user("q1.."), assistant("a1..")}, {user("q2.."), assistant("a2..")}},
+			want:    []llm.Message{user("q2.."), assistant("a2..")},
+		},
+		{
+			name:    "no leading assistant message",
+			budget:  3 * cost,
+			appends: [][]llm.Message{{user("q1.."), assistant("a1..")}, {user("q2.."), assistant("a2..")}},
+			want:    []llm.Message{user("q2.."), assistant("a2..")},
+		},
+		{
+			name:    "message over budget",
+			budget:  cost,
+			appends: [][]llm.Message{{user(strings.Repeat("x", 100))}},
+			want:    nil,
+		},
+		{
+			name:    "consecutive user messages",
+			budget:  2 * cost,
+			appends: [][]llm.Message{{user("q1..")}, {user("q2..")}, {user("q3..")}},
+			want:    []llm.Message{user("q2.."), user("q3..")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHistory(tt.budget, 0)
+			for _, messages := range tt.appends {
+				h.Append("channel", messages...)
+			}
+			if got := h.Messages("channel"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Messages() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryChannels(t *testing.T) {
+	h := NewHistory(1000, 0)
+	h.Append("a", llm.Message{Role: llm.RoleUser, Content: "hello"})
+	h.Append("b", llm.Message{Role: llm.RoleUser, Content: "hi"})
+
+	if got := h.Messages("a"); len(got) != 1 || got[0].Content != "hello" {
+		t.Errorf("Messages(a) = %+v", got)
+	}
+
+	h.Reset("a")
+	if got := h.Messages("a"); len(got) != 0 {
+		t.Errorf("Messages(a) after Reset = %+v, want none", got)
+	}
+	if got := h.Messages("b"); len(got) != 1 {
+		t.Errorf("Messages(b) after Reset(a) = %+v, want one", got)
+	}
+}
+
+func TestHistoryIdleTimeout(t *testing.T) {
+	h := NewHistory(1000, time.Millisecond)
+	h.Append("channel", llm.Message{Role: llm.RoleUser, Content: "hello"})
+	time.Sleep(5 * time.Millisecond)
+
+	if got := h.Messages("channel"); len(got) != 0 {
+		t.Errorf("Messages() after the idle timeout = %+v, want none", got)
+	}
+}
@@ -0,0 +1,16 @@
+// Package ai is layla's conversational mode backed by internal/llm.
+package ai
+
+import (
+	_ "embed"
+	"strings"
+)
+
+//go:embed system-prompt.txt
+var systemPrompt string
+
+// SystemPrompt returns the Layla persona sent as the first message of every
+// conversation.
+func SystemPrompt() string {
+	return strings.TrimSpace(systemPrompt)
+}
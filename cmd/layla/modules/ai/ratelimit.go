@@ -0,0 +1,56 @@
+package ai
+
+import (
+	"sync"
+	"time"
+)
+
+type userBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter is a token bucket per user, refilled with rate messages per
+// minute up to burst.
+type RateLimiter struct {
+	mu      sync.Mutex
+	users   map[string]*userBucket
+	perSec  float64
+	burst   float64
+	enabled bool
+}
+
+func NewRateLimiter(perMinute, burst int) *RateLimiter {
+	return &RateLimiter{
+		users:   make(map[string]*userBucket),
+		perSec:  float64(perMinute) / 60,
+		burst:   float64(max(burst, 1)),
+		enabled: perMinute > 0,
+	}
+}
+
+// Allow takes a token for userID. When none is left it returns false and how
+// long until the next one.
+func (l *RateLimiter) Allow(userID string) (bool, time.Duration) {
+	if !l.enabled {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, ok := l.users[userID]
+	if !ok {
+		b = &userBucket{tokens: l.burst, last: now}
+		l.users[userID] = b
+	}
+
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.perSec)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.perSec * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
@@ -0,0 +1,78 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"git.jakub.app/jakub/X/internal/llm"
+	"github.com/rs/zerolog/log"
+)
+
+// ToolFunc runs a tool with the JSON arguments given by the model and
+// returns the result passed back to it.
+type ToolFunc func(ctx context.Context, args json.RawMessage) (string, error)
+
+// Registry holds the tools the chat can call, it implements
+// llm.ToolExecutor.
+type Registry struct {
+	tools []llm.Tool
+	funcs map[string]ToolFunc
+}
+
+func NewRegistry() *Registry {
+	return &Registry{funcs: make(map[string]ToolFunc)}
+}
+
+// Register adds a tool, replacing a tool of the same name.
+func (r *Registry) Register(tool llm.Tool, fn ToolFunc) {
+	if _, ok := r.funcs[tool.Name]; ok {
+		for i := range r.tools {
+			if r.tools[i].Name == tool.Name {
+				r.tools[i] = tool
+			}
+		}
+	} else {
+		r.tools = append(r.tools, tool)
+	}
+	r.funcs[tool.Name] = fn
+}
+
+func (r *Registry) Tools() []llm.Tool {
+	return r.tools
+}
+
+func (r *Registry) Execute(ctx context.Context, call llm.ToolCall) (string, error) {
+	fn, ok := r.funcs[call.Name]
+	if !ok {
+		return "", fmt.Errorf("unknown tool %q", call.Name)
+	}
+
+	args := json.RawMessage(call.Arguments)
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+	if !json.Valid(args) {
+		return "", fmt.Errorf("arguments of %q are not valid JSON", call.Name)
+	}
+
+	start := time.Now()
+	result, err := fn(ctx, args)
+	log.Debug().Err(err).Str("tool", call.Name).RawJSON("arguments", args).Dur("took", time.Since(start)).Msg("tool called")
+	return result, err
+}
+
+// Schema is a shorthand for the JSON schema of an object with the given
+// properties.
+func Schema(properties map[string]any, required ...string) json.RawMessage {
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	data, _ := json.Marshal(schema)
+	return data
+}
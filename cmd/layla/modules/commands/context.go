@@ -0,0 +1,223 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"git.jakub.app/jakub/X/cmd/layla/modules/discord"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Context is passed to command and component handlers. Its Reply methods
+// send the initial response, or edit it when the handler deferred.
+type Context struct {
+	context.Context
+	Session     *discordgo.Session
+	Interaction *discordgo.InteractionCreate
+	// Subcommand is the name of the invoked subcommand, if any.
+	Subcommand string
+
+	options  map[string]*discordgo.ApplicationCommandInteractionDataOption
+	deferred bool
+	replied  bool
+}
+
+func newContext(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) *Context {
+	c := &Context{
+		Context:     ctx,
+		Session:     s,
+		Interaction: i,
+		options:     make(map[string]*discordgo.ApplicationCommandInteractionDataOption),
+	}
+
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return c
+	}
+
+	options := i.ApplicationCommandData().Options
+	if len(options) == 1 && options[0].Type == discordgo.ApplicationCommandOptionSubCommand {
+		c.Subcommand = options[0].Name
+		options = options[0].Options
+	}
+	for _, option := range options {
+		c.options[option.Name] = option
+	}
+	return c
+}
+
+// User returns the invoking user, in guilds and in DMs.
+func (c *Context) User() *discordgo.User {
+	if c.Interaction.Member != nil {
+		return c.Interaction.Member.User
+	}
+	return c.Interaction.User
+}
+
+// CustomID returns the custom ID of the clicked component or submitted
+// modal, empty for commands.
+func (c *Context) CustomID() string {
+	return customID(c.Interaction)
+}
+
+func customID(i *discordgo.InteractionCreate) string {
+	switch i.Type {
+	case discordgo.InteractionMessageComponent:
+		return i.MessageComponentData().CustomID
+	case discordgo.InteractionModalSubmit:
+		return i.ModalSubmitData().CustomID
+	}
+	return ""
+}
+
+// ComponentArgs returns the parts of the custom ID after the prefix, e.g.
+// ["day", "2024-01-02"] for "menu:day:2024-01-02".
+func (c *Context) ComponentArgs() []string {
+	_, state := discord.DecodeCustomID(c.CustomID())
+	return state
+}
+
+func (c *Context) Has(name string) bool {
+	_, ok := c.options[name]
+	return ok
+}
+
+// String returns the string option name, or def when it was not given.
+func (c *Context) String(name string, def string) string {
+	if option, ok := c.options[name]; ok && option.Type == discordgo.ApplicationCommandOptionString {
+		return option.StringValue()
+	}
+	return def
+}
+
+func (c *Context) Int(name string, def int64) int64 {
+	if option, ok := c.options[name]; ok && option.Type == discordgo.ApplicationCommandOptionInteger {
+		return option.IntValue()
+	}
+	return def
+}
+
+func (c *Context) Bool(name string, def bool) bool {
+	if option, ok := c.options[name]; ok && option.Type == discordgo.ApplicationCommandOptionBoolean {
+		return option.BoolValue()
+	}
+	return def
+}
+
+// RequireString returns the string option name or a UserError when it is
+// missing or empty.
+func (c *Context) RequireString(name string) (string, error) {
+	v := c.String(name, "")
+	if v == "" {
+		return "", Errorf("Missing required option `%s`.", name)
+	}
+	return v, nil
+}
+
+// Defer acknowledges the interaction, handlers that may take longer than
+// three seconds must call it before doing the work.
+func (c *Context) Defer(ephemeral bool) error {
+	if c.deferred || c.replied {
+		return nil
+	}
+
+	data := &discordgo.InteractionResponseData{}
+	if ephemeral {
+		data.Flags = discordgo.MessageFlagsEphemeral
+	}
+	err := c.Session.InteractionRespond(c.Interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: data,
+	}, discordgo.WithContext(c))
+	if err != nil {
+		return fmt.Errorf("can't defer interaction: %w", err)
+	}
+	c.deferred = true
+	return nil
+}
+
+func (c *Context) Reply(content string) error {
+	return c.respond(&discordgo.InteractionResponseData{Content: content})
+}
+
+func (c *Context) ReplyEphemeral(content string) error {
+	return c.respond(&discordgo.InteractionResponseData{Content: content, Flags: discordgo.MessageFlagsEphemeral})
+}
+
+func (c *Context) ReplyEmbeds(embeds ...*discordgo.MessageEmbed) error {
+	return c.respond(&discordgo.InteractionResponseData{Embeds: embeds})
+}
+
+// Update replaces the message the clicked component belongs to.
+func (c *Context) Update(data *discordgo.InteractionResponseData) error {
+	if c.deferred || c.replied {
+		return c.respond(data)
+	}
+
+	err := c.Session.InteractionRespond(c.Interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: data,
+	}, discordgo.WithContext(c))
+	if err == nil {
+		c.replied = true
+	}
+	return err
+}
+
+// DeferUpdate acknowledges a component interaction, the message is changed
+// later with Update.
+func (c *Context) DeferUpdate() error {
+	if c.deferred || c.replied {
+		return nil
+	}
+
+	err := c.Session.InteractionRespond(c.Interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	}, discordgo.WithContext(c))
+	if err != nil {
+		return fmt.Errorf("can't defer interaction: %w", err)
+	}
+	c.deferred = true
+	return nil
+}
+
+// Respond sends data as the response, for replies with components or other
+// fields the Reply helpers don't cover.
+func (c *Context) Respond(data *discordgo.InteractionResponseData) error {
+	return c.respond(data)
+}
+
+func (c *Context) respond(data *discordgo.InteractionResponseData) error {
+	if c.deferred || c.replied {
+		_, err := c.Session.InteractionResponseEdit(c.Interaction.Interaction, &discordgo.WebhookEdit{
+			Content:    &data.Content,
+			Embeds:     &data.Embeds,
+			Components: &data.Components,
+		}, discordgo.WithContext(c))
+		return err
+	}
+
+	err := c.Session.InteractionRespond(c.Interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	}, discordgo.WithContext(c))
+	if err == nil {
+		c.replied = true
+	}
+	return err
+}
+
+// replyError answers with an ephemeral message, as a follow-up when the
+// interaction was already answered.
+func (c *Context) replyError(message string) {
+	// The handler context may already be cancelled, errors still need an
+	// answer.
+	c.Context = context.Background()
+	if c.deferred || c.replied {
+		c.Session.FollowupMessageCreate(c.Interaction.Interaction, false, &discordgo.WebhookParams{
+			Content: "⚠️ " + message,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+	c.ReplyEphemeral("⚠️ " + message)
+}
@@ -0,0 +1,292 @@
+// Package commands dispatches Discord slash commands to their handlers.
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"git.jakub.app/jakub/X/cmd/layla/modules/discord"
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog/log"
+)
+
+// handlerTimeout bounds a single command, Discord stops accepting follow-ups
+// of an interaction after 15 minutes.
+const handlerTimeout = 10 * time.Minute
+
+type Handler func(ctx *Context) error
+
+// Command is a slash command. A command either has a Handler or
+// Subcommands, which are registered as sub-command options.
+type Command struct {
+	Name        string
+	Description string
+	Options     []*discordgo.ApplicationCommandOption
+	Subcommands []*Command
+	// Permissions are required from guild members, e.g.
+	// discordgo.PermissionManageMessages. Commands with permissions can't be
+	// used in DMs, except by admins.
+	Permissions int64
+	// AdminOnly limits the command to the router admins.
+	AdminOnly bool
+	Handler   Handler
+}
+
+// UserError is shown to the user as is, other errors are logged and answered
+// with a generic message.
+type UserError struct {
+	Message string
+}
+
+func (e *UserError) Error() string {
+	return e.Message
+}
+
+func Errorf(format string, args ...any) error {
+	return &UserError{Message: fmt.Sprintf(format, args...)}
+}
+
+type Router struct {
+	mu         sync.RWMutex
+	commands   map[string]*Command
+	components map[string]Handler
+	admins     []string
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	inflight sync.WaitGroup
+	closed   bool
+}
+
+// NewRouter returns a router whose AdminOnly commands can be used by the
+// given user IDs.
+func NewRouter(admins []string) *Router {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Router{
+		commands:   make(map[string]*Command),
+		components: make(map[string]Handler),
+		admins:     admins,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+func (r *Router) Add(cmds ...*Command) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, cmd := range cmds {
+		r.commands[cmd.Name] = cmd
+	}
+}
+
+// AddComponent routes message components, e.g. buttons, and modal submits
+// whose custom ID is prefix or starts with prefix + ":" to h.
+func (r *Router) AddComponent(prefix string, h Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.components[prefix] = h
+}
+
+func (r *Router) Commands() []*Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cmds := make([]*Command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		cmds = append(cmds, cmd)
+	}
+	slices.SortFunc(cmds, func(a, b *Command) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return cmds
+}
+
+// Register overwrites the application commands with the router's commands.
+// An empty guildID registers them globally, which takes up to an hour to
+// show up, a guild is updated immediately.
+func (r *Router) Register(s *discordgo.Session, guildID string) error {
+	var appCommands []*discordgo.ApplicationCommand
+	for _, cmd := range r.Commands() {
+		appCommands = append(appCommands, cmd.applicationCommand())
+	}
+
+	_, err := s.ApplicationCommandBulkOverwrite(s.State.User.ID, guildID, appCommands)
+	if err != nil {
+		return fmt.Errorf("can't register commands: %w", err)
+	}
+	return nil
+}
+
+func (cmd *Command) applicationCommand() *discordgo.ApplicationCommand {
+	ac := &discordgo.ApplicationCommand{
+		Name:        cmd.Name,
+		Description: cmd.Description,
+		Options:     cmd.options(),
+	}
+	if cmd.Permissions != 0 {
+		permissions := cmd.Permissions
+		ac.DefaultMemberPermissions = &permissions
+	}
+	return ac
+}
+
+func (cmd *Command) options() []*discordgo.ApplicationCommandOption {
+	if len(cmd.Subcommands) == 0 {
+		return cmd.Options
+	}
+
+	var options []*discordgo.ApplicationCommandOption
+	for _, sub := range cmd.Subcommands {
+		options = append(options, &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        sub.Name,
+			Description: sub.Description,
+			Options:     sub.Options,
+		})
+	}
+	return options
+}
+
+// Handle is a discordgo handler for InteractionCreate events.
+func (r *Router) Handle(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand, discordgo.InteractionMessageComponent, discordgo.InteractionModalSubmit:
+	default:
+		return
+	}
+
+	r.mu.RLock()
+	if r.closed {
+		r.mu.RUnlock()
+		return
+	}
+	r.inflight.Add(1)
+	var (
+		cmd     *Command
+		handler Handler
+		name    string
+	)
+	if i.Type == discordgo.InteractionApplicationCommand {
+		name = i.ApplicationCommandData().Name
+		cmd = r.commands[name]
+	} else {
+		name, _ = discord.DecodeCustomID(customID(i))
+		handler = r.components[name]
+	}
+	r.mu.RUnlock()
+	defer r.inflight.Done()
+
+	if cmd == nil && handler == nil {
+		log.Warn().Str("name", name).Msg("no handler for interaction")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.ctx, handlerTimeout)
+	defer cancel()
+
+	c := newContext(ctx, s, i)
+	logger := log.With().
+		Str("command", name).
+		Str("userId", c.User().ID).
+		Str("guildId", i.GuildID).
+		Str("channelId", i.ChannelID).
+		Logger()
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			logger.Error().Interface("panic", rec).Bytes("stack", debug.Stack()).Msg("command panicked")
+			c.replyError("Something went wrong.")
+		}
+	}()
+
+	var err error
+	if cmd != nil {
+		handler, err = r.resolve(c, cmd)
+	}
+	if err == nil {
+		start := time.Now()
+		err = handler(c)
+		logger.Info().Str("subcommand", c.Subcommand).Str("customId", c.CustomID()).Dur("duration", time.Since(start)).Err(err).Msg("interaction handled")
+	}
+
+	if err != nil {
+		var userErr *UserError
+		if errors.As(err, &userErr) {
+			c.replyError(userErr.Message)
+			return
+		}
+		logger.Error().Err(err).Msg("command failed")
+		c.replyError("Something went wrong.")
+	}
+}
+
+// resolve picks the subcommand handler and checks permissions.
+func (r *Router) resolve(c *Context, cmd *Command) (Handler, error) {
+	if err := r.authorize(c, cmd); err != nil {
+		return nil, err
+	}
+	if len(cmd.Subcommands) == 0 {
+		return cmd.Handler, nil
+	}
+
+	for _, sub := range cmd.Subcommands {
+		if sub.Name != c.Subcommand {
+			continue
+		}
+		if err := r.authorize(c, sub); err != nil {
+			return nil, err
+		}
+		return sub.Handler, nil
+	}
+	return nil, fmt.Errorf("unknown subcommand %q of %s", c.Subcommand, cmd.Name)
+}
+
+func (r *Router) authorize(c *Context, cmd *Command) error {
+	if r.IsAdmin(c.User().ID) {
+		return nil
+	}
+	if cmd.AdminOnly {
+		return Errorf("This command is only available to the bot admins.")
+	}
+	if cmd.Permissions == 0 {
+		return nil
+	}
+
+	member := c.Interaction.Member
+	if member == nil || member.Permissions&cmd.Permissions != cmd.Permissions {
+		return Errorf("You don't have permission to use this command.")
+	}
+	return nil
+}
+
+func (r *Router) IsAdmin(userID string) bool {
+	return slices.Contains(r.admins, userID)
+}
+
+// Close stops accepting commands, cancels the running ones after ctx is done
+// and waits for them to return.
+func (r *Router) Close(ctx context.Context) {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		r.cancel()
+		<-done
+	}
+	r.cancel()
+}
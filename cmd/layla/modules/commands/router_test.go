@@ -0,0 +1,317 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// apiRequest is a request the router sent to the Discord API.
+type apiRequest struct {
+	method string
+	path   string
+	body   struct {
+		Type    discordgo.InteractionResponseType `json:"type"`
+		Content string                            `json:"content"`
+		Flags   discordgo.MessageFlags            `json:"flags"`
+		Data    struct {
+			Content string                 `json:"content"`
+			Flags   discordgo.MessageFlags `json:"flags"`
+		} `json:"data"`
+	}
+}
+
+// fakeAPI answers every Discord API request with 204 and records it.
+type fakeAPI struct {
+	mu       sync.Mutex
+	requests []apiRequest
+}
+
+func (f *fakeAPI) RoundTrip(r *http.Request) (*http.Response, error) {
+	req := apiRequest{method: r.Method, path: r.URL.Path}
+	if r.Body != nil {
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &req.body)
+	}
+
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.mu.Unlock()
+
+	return &http.Response{
+		StatusCode: http.StatusNoContent,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    r,
+	}, nil
+}
+
+func newFakeSession(t *testing.T) (*discordgo.Session, *fakeAPI) {
+	t.Helper()
+	s, err := discordgo.New("Bot test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := &fakeAPI{}
+	s.Client = &http.Client{Transport: api}
+	return s, api
+}
+
+func commandInteraction(name string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	return interaction(discordgo.InteractionApplicationCommand, discordgo.ApplicationCommandInteractionData{Name: name, Options: options})
+}
+
+func interaction(typ discordgo.InteractionType, data discordgo.InteractionData) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:     "1",
+		AppID:  "2",
+		Token:  "token",
+		Type:   typ,
+		Data:   data,
+		Member: &discordgo.Member{User: &discordgo.User{ID: "user"}},
+	}}
+}
+
+func TestRouterSubcommands(t *testing.T) {
+	var called []string
+	handler := func(name string) Handler {
+		return func(c *Context) error {
+			called = append(called, name+":"+c.Subcommand+":"+c.String("day", ""))
+			return nil
+		}
+	}
+
+	r := NewRouter(nil)
+	r.Add(
+		&Command{Name: "menu", Subcommands: []*Command{
+			{Name: "day", Handler: handler("day")},
+			{Name: "week", Handler: handler("week")},
+		}},
+		&Command{Name: "ping", Handler: handler("ping")},
+	)
+
+	tests := []struct {
+		name        string
+		interaction *discordgo.InteractionCreate
+		want        []string
+		wantError   string
+	}{
+		{
+			name: "subcommand",
+			interaction: commandInteraction("menu", &discordgo.ApplicationCommandInteractionDataOption{
+				Name: "day",
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					{Name: "day", Type: discordgo.ApplicationCommandOptionString, Value: "tomorrow"},
+				},
+			}),
+			want: []string{"day:day:tomorrow"},
+		},
+		{
+			name: "other subcommand",
+			interaction: commandInteraction("menu", &discordgo.ApplicationCommandInteractionDataOption{
+				Name: "week",
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+			}),
+			want: []string{"week:week:"},
+		},
+		{
+			name:        "command without subcommands",
+			interaction: commandInteraction("ping"),
+			want:        []string{"ping::"},
+		},
+		{
+			name: "unknown subcommand",
+			interaction: commandInteraction("menu", &discordgo.ApplicationCommandInteractionDataOption{
+				Name: "month",
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+			}),
+			wantError: "⚠️ Something went wrong.",
+		},
+		{
+			name:        "unknown command",
+			interaction: commandInteraction("pong"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called = nil
+			s, api := newFakeSession(t)
+			r.Handle(s, tt.interaction)
+
+			if !slices.Equal(called, tt.want) {
+				t.Errorf("called handlers %q, want %q", called, tt.want)
+			}
+			checkErrorReply(t, api, tt.wantError)
+		})
+	}
+}
+
+func TestRouterComponents(t *testing.T) {
+	var called []string
+	handler := func(name string) Handler {
+		return func(c *Context) error {
+			called = append(called, name+":"+strings.Join(c.ComponentArgs(), ","))
+			return nil
+		}
+	}
+
+	r := NewRouter(nil)
+	r.AddComponent("menu", handler("menu"))
+	r.AddComponent("menu-week", handler("menu-week"))
+	r.AddComponent("wait", handler("wait"))
+
+	button := func(customID string) *discordgo.InteractionCreate {
+		return interaction(discordgo.InteractionMessageComponent, discordgo.MessageComponentInteractionData{CustomID: customID})
+	}
+	tests := []struct {
+		name        string
+		interaction *discordgo.InteractionCreate
+		want        []string
+	}{
+		{"prefix only", button("menu"), []string{"menu:"}},
+		{"prefix with state", button("menu:day:2024-01-02"), []string{"menu:day,2024-01-02"}},
+		{"longer prefix", button("menu-week:1"), []string{"menu-week:1"}},
+		{"escaped state", button("wait:abc:12%3A30"), []string{"wait:abc,12:30"}},
+		{"modal submit", interaction(discordgo.InteractionModalSubmit, discordgo.ModalSubmitInteractionData{CustomID: "wait:abc"}), []string{"wait:abc"}},
+		{"prefix of a prefix", button("men:day"), nil},
+		{"unknown prefix", button("menus"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called = nil
+			s, api := newFakeSession(t)
+			r.Handle(s, tt.interaction)
+
+			if !slices.Equal(called, tt.want) {
+				t.Errorf("called handlers %q, want %q", called, tt.want)
+			}
+			checkErrorReply(t, api, "")
+		})
+	}
+}
+
+func TestRouterErrorReplies(t *testing.T) {
+	tests := []struct {
+		name      string
+		cmd       *Command
+		member    *discordgo.Member
+		wantError string
+		// wantFollowup is set when the handler answered before failing.
+		wantFollowup bool
+	}{
+		{
+			name:      "user error",
+			cmd:       &Command{Handler: func(c *Context) error { return Errorf("No menu for %s.", "Monday") }},
+			wantError: "⚠️ No menu for Monday.",
+		},
+		{
+			name:      "wrapped user error",
+			cmd:       &Command{Handler: func(c *Context) error { return errors.Join(errors.New("lookup"), Errorf("Not found.")) }},
+			wantError: "⚠️ Not found.",
+		},
+		{
+			name: "user error after defer",
+			cmd: &Command{Handler: func(c *Context) error {
+				if err := c.Defer(false); err != nil {
+					return err
+				}
+				return Errorf("Too late.")
+			}},
+			wantError:    "⚠️ Too late.",
+			wantFollowup: true,
+		},
+		{
+			name:      "internal error",
+			cmd:       &Command{Handler: func(c *Context) error { return errors.New("database is down") }},
+			wantError: "⚠️ Something went wrong.",
+		},
+		{
+			name:      "panic",
+			cmd:       &Command{Handler: func(c *Context) error { panic("boom") }},
+			wantError: "⚠️ Something went wrong.",
+		},
+		{
+			name:      "admin only",
+			cmd:       &Command{AdminOnly: true, Handler: func(c *Context) error { return nil }},
+			wantError: "⚠️ This command is only available to the bot admins.",
+		},
+		{
+			name:      "missing permissions",
+			cmd:       &Command{Permissions: discordgo.PermissionManageMessages, Handler: func(c *Context) error { return nil }},
+			wantError: "⚠️ You don't have permission to use this command.",
+		},
+		{
+			name:   "permissions",
+			cmd:    &Command{Permissions: discordgo.PermissionManageMessages, Handler: func(c *Context) error { return nil }},
+			member: &discordgo.Member{User: &discordgo.User{ID: "user"}, Permissions: discordgo.PermissionManageMessages},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cmd.Name = "test"
+			r := NewRouter([]string{"admin"})
+			r.Add(tt.cmd)
+
+			i := commandInteraction("test")
+			if tt.member != nil {
+				i.Member = tt.member
+			}
+			s, api := newFakeSession(t)
+			r.Handle(s, i)
+
+			checkErrorReply(t, api, tt.wantError)
+			if tt.wantError == "" {
+				return
+			}
+			last := api.requests[len(api.requests)-1]
+			isFollowup := strings.HasPrefix(last.path, "/api/v9/webhooks/")
+			if isFollowup != tt.wantFollowup {
+				t.Errorf("error sent to %s, want a follow-up %v", last.path, tt.wantFollowup)
+			}
+		})
+	}
+}
+
+// checkErrorReply checks that the last API request is an ephemeral reply or
+// follow-up with want, or that no error was sent when want is empty.
+func checkErrorReply(t *testing.T, api *fakeAPI, want string) {
+	t.Helper()
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	if want == "" {
+		for _, req := range api.requests {
+			if strings.HasPrefix(req.body.Data.Content, "⚠️") || strings.HasPrefix(req.body.Content, "⚠️") {
+				t.Errorf("unexpected error reply %s %s", req.method, req.path)
+			}
+		}
+		return
+	}
+
+	if len(api.requests) == 0 {
+		t.Fatalf("no reply, want %q", want)
+	}
+	req := api.requests[len(api.requests)-1]
+	content, flags := req.body.Data.Content, req.body.Data.Flags
+	if strings.HasSuffix(req.path, "/callback") {
+		if req.body.Type != discordgo.InteractionResponseChannelMessageWithSource {
+			t.Errorf("reply type = %d, want %d", req.body.Type, discordgo.InteractionResponseChannelMessageWithSource)
+		}
+	} else {
+		content, flags = req.body.Content, req.body.Flags
+	}
+	if content != want {
+		t.Errorf("reply = %q, want %q", content, want)
+	}
+	if flags&discordgo.MessageFlagsEphemeral == 0 {
+		t.Errorf("reply flags = %d, want ephemeral", flags)
+	}
+}
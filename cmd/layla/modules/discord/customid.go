@@ -0,0 +1,43 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CustomIDLimit is the maximum length of a component or modal custom ID.
+const CustomIDLimit = 100
+
+var ErrCustomIDTooLong = errors.New("custom id is too long")
+
+var (
+	customIDEscaper   = strings.NewReplacer("%", "%25", ":", "%3A")
+	customIDUnescaper = strings.NewReplacer("%3A", ":", "%25", "%")
+)
+
+// EncodeCustomID joins prefix and state into a custom ID in the
+// "prefix:state:state" form, escaping colons in the state. Discord gives the
+// custom ID back on every interaction, so it can carry small state without
+// keeping it in memory.
+func EncodeCustomID(prefix string, state ...string) (string, error) {
+	parts := []string{prefix}
+	for _, s := range state {
+		parts = append(parts, customIDEscaper.Replace(s))
+	}
+
+	id := strings.Join(parts, ":")
+	if len(id) > CustomIDLimit {
+		return "", fmt.Errorf("%w: %d bytes, the limit is %d", ErrCustomIDTooLong, len(id), CustomIDLimit)
+	}
+	return id, nil
+}
+
+// DecodeCustomID splits a custom ID made by EncodeCustomID.
+func DecodeCustomID(id string) (prefix string, state []string) {
+	parts := strings.Split(id, ":")
+	for _, s := range parts[1:] {
+		state = append(state, customIDUnescaper.Replace(s))
+	}
+	return parts[0], state
+}
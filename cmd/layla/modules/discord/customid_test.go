@@ -0,0 +1,57 @@
+package discord
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCustomIDRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		state  []string
+		want   string
+	}{
+		{"prefix only", "menu", nil, "menu"},
+		{"state", "menu", []string{"day", "2024-01-02"}, "menu:day:2024-01-02"},
+		{"colon", "wait", []string{"abc", "12:30"}, "wait:abc:12%3A30"},
+		{"percent", "wait", []string{"100%", "%3A"}, "wait:100%25:%253A"},
+		{"empty state", "menu", []string{"", "x"}, "menu::x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := EncodeCustomID(tt.prefix, tt.state...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != tt.want {
+				t.Errorf("EncodeCustomID() = %q, want %q", id, tt.want)
+			}
+
+			prefix, state := DecodeCustomID(id)
+			if prefix != tt.prefix || !slices.Equal(state, tt.state) {
+				t.Errorf("DecodeCustomID(%q) = %q, %q; want %q, %q", id, prefix, state, tt.prefix, tt.state)
+			}
+		})
+	}
+}
+
+func TestEncodeCustomIDLimit(t *testing.T) {
+	// "p:" plus the state fills the limit exactly.
+	if _, err := EncodeCustomID("p", strings.Repeat("x", CustomIDLimit-2)); err != nil {
+		t.Errorf("EncodeCustomID() at the limit: %v", err)
+	}
+
+	_, err := EncodeCustomID("p", strings.Repeat("x", CustomIDLimit-1))
+	if !errors.Is(err, ErrCustomIDTooLong) {
+		t.Errorf("EncodeCustomID() over the limit: %v, want %v", err, ErrCustomIDTooLong)
+	}
+
+	// Escaping counts against the limit.
+	_, err = EncodeCustomID("p", strings.Repeat(":", CustomIDLimit/3))
+	if !errors.Is(err, ErrCustomIDTooLong) {
+		t.Errorf("EncodeCustomID() with escaped state over the limit: %v, want %v", err, ErrCustomIDTooLong)
+	}
+}
@@ -0,0 +1,166 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+)
+
+// MaxFilesPerMessage is the number of attachments Discord accepts per
+// message.
+const MaxFilesPerMessage = 10
+
+// File is uploaded as an attachment of a webhook message. Embeds reference it
+// with AttachmentURL(Name), e.g. as image or thumbnail.
+type File struct {
+	Name        string
+	ContentType string
+	Description string
+	Data        []byte
+}
+
+// Attachment is a file attached to a sent message.
+type Attachment struct {
+	ID          string `json:"id"`
+	Filename    string `json:"filename"`
+	Description string `json:"description,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int    `json:"size"`
+	URL         string `json:"url"`
+}
+
+// attachmentPayload describes the n-th uploaded file in payload_json.
+type attachmentPayload struct {
+	ID          int    `json:"id"`
+	Filename    string `json:"filename"`
+	Description string `json:"description,omitempty"`
+}
+
+// AttachmentURL returns the URL an embed uses to show an attached file.
+func AttachmentURL(name string) string {
+	return "attachment://" + name
+}
+
+// encodeMessage returns msg as a JSON body, or as multipart form with
+// payload_json and files[n] parts when it has files.
+func encodeMessage(msg WebhookMessage) ([]byte, string, error) {
+	if len(msg.Files) == 0 {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to marshal webhook data: %w", err)
+		}
+		return data, "application/json", nil
+	}
+
+	if len(msg.Files) > MaxFilesPerMessage {
+		return nil, "", fmt.Errorf("message has %d files, the limit is %d", len(msg.Files), MaxFilesPerMessage)
+	}
+
+	payload := struct {
+		WebhookMessage
+		Attachments []attachmentPayload `json:"attachments"`
+	}{WebhookMessage: msg}
+	for i, f := range msg.Files {
+		payload.Attachments = append(payload.Attachments, attachmentPayload{ID: i, Filename: f.Name, Description: f.Description})
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal webhook data: %w", err)
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="payload_json"`)
+	header.Set("Content-Type", "application/json")
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := part.Write(payloadJSON); err != nil {
+		return nil, "", err
+	}
+
+	for i, f := range msg.Files {
+		contentType := f.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="files[%d]"; filename="%s"`, i, escapeQuotes(f.Name)))
+		header.Set("Content-Type", contentType)
+		part, err := w.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(f.Data); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), w.FormDataContentType(), nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// referencedFiles returns the files referenced through attachment:// URLs by
+// the embeds.
+func referencedFiles(embeds []Embed, files []File) []File {
+	refs := make(map[string]bool)
+	for _, e := range embeds {
+		for _, u := range []string{imageURL(e.Image), thumbnailURL(e.Thumbnail), authorIconURL(e.Author), footerIconURL(e.Footer)} {
+			if name, ok := strings.CutPrefix(u, "attachment://"); ok {
+				refs[name] = true
+			}
+		}
+	}
+
+	var result []File
+	for _, f := range files {
+		if refs[f.Name] {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+func imageURL(i *EmbedImage) string {
+	if i == nil {
+		return ""
+	}
+	return i.URL
+}
+
+func thumbnailURL(t *EmbedThumbnail) string {
+	if t == nil {
+		return ""
+	}
+	return t.URL
+}
+
+func authorIconURL(a *EmbedAuthor) string {
+	if a == nil {
+		return ""
+	}
+	return a.IconURL
+}
+
+func footerIconURL(f *EmbedFooter) string {
+	if f == nil {
+		return ""
+	}
+	return f.IconURL
+}
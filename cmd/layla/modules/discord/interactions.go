@@ -0,0 +1,298 @@
+package discord
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog/log"
+)
+
+// WaitPrefix is the custom ID prefix of components and modals made by a
+// Waiter. The interaction router passes them on to Interactions.Deliver.
+const WaitPrefix = "wait"
+
+var ErrTimeout = errors.New("discord: nobody responded in time")
+
+// ReactionHandler handles a reaction being added or removed.
+type ReactionHandler func(s *discordgo.Session, r *discordgo.MessageReaction, added bool)
+
+// Interactions waits for the answers to one-off prompts, which are routed to
+// it by custom ID, and routes the reactions of a session. Reactions need the
+// GuildMessageReactions and DirectMessageReactions intents.
+type Interactions struct {
+	session *discordgo.Session
+	removes []func()
+
+	mu        sync.RWMutex
+	reactions []ReactionHandler
+	waiters   map[string]*Waiter
+	// reactionWaiters are keyed by message ID.
+	reactionWaiters map[string][]*reactionWaiter
+}
+
+type reactionWaiter struct {
+	filter func(*discordgo.MessageReaction) bool
+	ch     chan *discordgo.MessageReaction
+}
+
+func NewInteractions(d *Discord) *Interactions {
+	in := &Interactions{
+		session:         d.Session(),
+		waiters:         make(map[string]*Waiter),
+		reactionWaiters: make(map[string][]*reactionWaiter),
+	}
+
+	in.removes = append(in.removes,
+		in.session.AddHandler(func(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+			in.onReaction(s, r.MessageReaction, true)
+		}),
+		in.session.AddHandler(func(s *discordgo.Session, r *discordgo.MessageReactionRemove) {
+			in.onReaction(s, r.MessageReaction, false)
+		}),
+	)
+	return in
+}
+
+// HandleReaction calls h for every reaction added or removed.
+func (in *Interactions) HandleReaction(h ReactionHandler) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.reactions = append(in.reactions, h)
+}
+
+// Close stops routing events, pending waits time out.
+func (in *Interactions) Close() {
+	for _, remove := range in.removes {
+		remove()
+	}
+}
+
+// Deliver passes a component or modal submit with the WaitPrefix custom ID
+// on to its waiter, or tells the user the prompt has expired when nobody
+// waits for it anymore.
+func (in *Interactions) Deliver(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var customID string
+	switch i.Type {
+	case discordgo.InteractionMessageComponent:
+		customID = i.MessageComponentData().CustomID
+	case discordgo.InteractionModalSubmit:
+		customID = i.ModalSubmitData().CustomID
+	}
+
+	var w *Waiter
+	if prefix, state := DecodeCustomID(customID); prefix == WaitPrefix && len(state) > 0 {
+		in.mu.RLock()
+		w = in.waiters[state[0]]
+		in.mu.RUnlock()
+
+		if w != nil {
+			select {
+			case w.ch <- WaitEvent{Interaction: i, State: state[1:]}:
+				return
+			default:
+			}
+		}
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "This prompt has expired.",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("can't respond to expired prompt")
+	}
+}
+
+func (in *Interactions) onReaction(s *discordgo.Session, r *discordgo.MessageReaction, added bool) {
+	if s.State.User != nil && r.UserID == s.State.User.ID {
+		return
+	}
+
+	in.mu.RLock()
+	handlers := in.reactions
+	var waiters []*reactionWaiter
+	if added {
+		waiters = in.reactionWaiters[r.MessageID]
+	}
+	in.mu.RUnlock()
+
+	for _, w := range waiters {
+		if w.filter == nil || w.filter(r) {
+			select {
+			case w.ch <- r:
+			default:
+			}
+		}
+	}
+
+	for _, h := range handlers {
+		h(s, r, added)
+	}
+}
+
+// AwaitReaction waits for a reaction added to a message and matching filter,
+// nil matches any. The bot's own reactions are ignored.
+func (in *Interactions) AwaitReaction(ctx context.Context, messageID string, filter func(*discordgo.MessageReaction) bool, timeout time.Duration) (*discordgo.MessageReaction, error) {
+	w := &reactionWaiter{filter: filter, ch: make(chan *discordgo.MessageReaction, 1)}
+
+	in.mu.Lock()
+	in.reactionWaiters[messageID] = append(in.reactionWaiters[messageID], w)
+	in.mu.Unlock()
+
+	defer func() {
+		in.mu.Lock()
+		defer in.mu.Unlock()
+		waiters := in.reactionWaiters[messageID]
+		for i := range waiters {
+			if waiters[i] == w {
+				waiters = append(waiters[:i], waiters[i+1:]...)
+				break
+			}
+		}
+		if len(waiters) == 0 {
+			delete(in.reactionWaiters, messageID)
+		} else {
+			in.reactionWaiters[messageID] = waiters
+		}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-w.ch:
+		return r, nil
+	case <-timer.C:
+		return nil, ErrTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// WaitEvent is an interaction received by a Waiter.
+type WaitEvent struct {
+	Interaction *discordgo.InteractionCreate
+	// State is the state given to Waiter.CustomID.
+	State []string
+}
+
+// Waiter receives the interactions of components and modals whose custom IDs
+// it made, for prompts that wait for an answer in place. Interactions that
+// arrive after Close are answered with an ephemeral "expired" note.
+type Waiter struct {
+	in *Interactions
+	id string
+	ch chan WaitEvent
+}
+
+func (in *Interactions) NewWaiter() *Waiter {
+	id := make([]byte, 6)
+	rand.Read(id)
+
+	w := &Waiter{in: in, id: hex.EncodeToString(id), ch: make(chan WaitEvent, 8)}
+
+	in.mu.Lock()
+	in.waiters[w.id] = w
+	in.mu.Unlock()
+	return w
+}
+
+// CustomID returns a custom ID routed to this waiter.
+func (w *Waiter) CustomID(state ...string) (string, error) {
+	return EncodeCustomID(WaitPrefix, append([]string{w.id}, state...)...)
+}
+
+// Next waits for the next interaction. The caller has to respond to it
+// within the three seconds Discord allows.
+func (w *Waiter) Next(ctx context.Context, timeout time.Duration) (WaitEvent, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case e := <-w.ch:
+		return e, nil
+	case <-timer.C:
+		return WaitEvent{}, ErrTimeout
+	case <-ctx.Done():
+		return WaitEvent{}, ctx.Err()
+	}
+}
+
+func (w *Waiter) Close() {
+	w.in.mu.Lock()
+	defer w.in.mu.Unlock()
+	delete(w.in.waiters, w.id)
+}
+
+// InteractionUser returns the user of an interaction in a guild or a DM.
+func InteractionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// ModalValues returns the text inputs of a modal submit by custom ID.
+func ModalValues(i *discordgo.InteractionCreate) map[string]string {
+	values := make(map[string]string)
+	for _, row := range i.ModalSubmitData().Components {
+		actions, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, component := range actions.Components {
+			if input, ok := component.(*discordgo.TextInput); ok {
+				values[input.CustomID] = input.Value
+			}
+		}
+	}
+	return values
+}
+
+// DisableComponents returns a copy of the action rows with every button and
+// select disabled, used to close a prompt.
+func DisableComponents(rows []discordgo.MessageComponent) []discordgo.MessageComponent {
+	disabled := make([]discordgo.MessageComponent, 0, len(rows))
+	for _, row := range rows {
+		actions, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			if value, ok := row.(discordgo.ActionsRow); ok {
+				actions = &value
+			} else {
+				disabled = append(disabled, row)
+				continue
+			}
+		}
+
+		copied := discordgo.ActionsRow{}
+		for _, component := range actions.Components {
+			switch c := component.(type) {
+			case discordgo.Button:
+				c.Disabled = true
+				component = c
+			case *discordgo.Button:
+				b := *c
+				b.Disabled = true
+				component = b
+			case discordgo.SelectMenu:
+				c.Disabled = true
+				component = c
+			case *discordgo.SelectMenu:
+				m := *c
+				m.Disabled = true
+				component = m
+			}
+			copied.Components = append(copied.Components, component)
+		}
+		disabled = append(disabled, copied)
+	}
+	return disabled
+}
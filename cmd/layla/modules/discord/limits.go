@@ -0,0 +1,227 @@
+package discord
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// MessageContentLimit is the maximum length of a message content.
+const MessageContentLimit = 2000
+
+// Embed limits enforced by Discord, counted in characters.
+// https://discord.com/developers/docs/resources/message#embed-object-embed-limits
+const (
+	EmbedTitleLimit       = 256
+	EmbedDescriptionLimit = 4096
+	EmbedFieldsLimit      = 25
+	EmbedFieldNameLimit   = 256
+	EmbedFieldValueLimit  = 1024
+	EmbedFooterTextLimit  = 2048
+	EmbedAuthorNameLimit  = 256
+	EmbedTotalLimit       = 6000
+	EmbedsPerMessageLimit = 10
+)
+
+// Truncate shortens s to at most limit characters, marking the cut with an
+// ellipsis.
+func Truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
+
+// Length returns the number of characters Discord counts towards
+// EmbedTotalLimit.
+func (e Embed) Length() int {
+	n := utf8.RuneCountInString(e.Title) + utf8.RuneCountInString(e.Description)
+	if e.Footer != nil {
+		n += utf8.RuneCountInString(e.Footer.Text)
+	}
+	if e.Author != nil {
+		n += utf8.RuneCountInString(e.Author.Name)
+	}
+	for _, f := range e.Fields {
+		n += f.length()
+	}
+	return n
+}
+
+func (f EmbedField) length() int {
+	return utf8.RuneCountInString(f.Name) + utf8.RuneCountInString(f.Value)
+}
+
+// SplitEmbed truncates oversized texts of e and spreads its fields over as
+// many embeds as needed to stay within the per-embed limits. The following
+// embeds repeat the title and color, the author, URL, thumbnail and
+// description stay on the first one and the footer, image and timestamp move
+// to the last one.
+func SplitEmbed(e Embed) []Embed {
+	e.Title = Truncate(e.Title, EmbedTitleLimit)
+	e.Description = Truncate(e.Description, EmbedDescriptionLimit)
+	if e.Footer != nil {
+		footer := *e.Footer
+		footer.Text = Truncate(footer.Text, EmbedFooterTextLimit)
+		e.Footer = &footer
+	}
+	if e.Author != nil {
+		author := *e.Author
+		author.Name = Truncate(author.Name, EmbedAuthorNameLimit)
+		e.Author = &author
+	}
+
+	current := Embed{
+		Title:       e.Title,
+		Description: e.Description,
+		URL:         e.URL,
+		Color:       e.Color,
+		Thumbnail:   e.Thumbnail,
+		Author:      e.Author,
+	}
+	var embeds []Embed
+	for _, f := range e.Fields {
+		f.Name = Truncate(f.Name, EmbedFieldNameLimit)
+		f.Value = Truncate(f.Value, EmbedFieldValueLimit)
+		// Discord rejects empty field names and values.
+		if f.Name == "" {
+			f.Name = "\u200b"
+		}
+		if f.Value == "" {
+			f.Value = "\u200b"
+		}
+
+		// Keep room for the footer that goes on the last embed.
+		if len(current.Fields) == EmbedFieldsLimit || current.Length()+f.length()+footerLength(e) > EmbedTotalLimit {
+			embeds = append(embeds, current)
+			current = Embed{Title: e.Title, Color: e.Color}
+		}
+		current.Fields = append(current.Fields, f)
+	}
+
+	current.Footer = e.Footer
+	current.Image = e.Image
+	current.Timestamp = e.Timestamp
+	return append(embeds, current)
+}
+
+func footerLength(e Embed) int {
+	if e.Footer == nil {
+		return 0
+	}
+	return utf8.RuneCountInString(e.Footer.Text)
+}
+
+// GroupEmbeds packs embeds into messages of at most EmbedsPerMessageLimit
+// embeds whose combined length stays within EmbedTotalLimit. The embeds must
+// already fit the per-embed limits, see SplitEmbed.
+func GroupEmbeds(embeds []Embed) [][]Embed {
+	var (
+		groups  [][]Embed
+		current []Embed
+		length  int
+	)
+	for _, e := range embeds {
+		n := e.Length()
+		if len(current) > 0 && (len(current) == EmbedsPerMessageLimit || length+n > EmbedTotalLimit) {
+			groups = append(groups, current)
+			current, length = nil, 0
+		}
+		current = append(current, e)
+		length += n
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+// Validate reports the first Discord limit msg exceeds.
+func (msg WebhookMessage) Validate() error {
+	if err := checkLength("content", msg.Content, MessageContentLimit); err != nil {
+		return err
+	}
+	if len(msg.Embeds) > EmbedsPerMessageLimit {
+		return fmt.Errorf("message has %d embeds, the limit is %d", len(msg.Embeds), EmbedsPerMessageLimit)
+	}
+
+	var total int
+	for i, e := range msg.Embeds {
+		if err := e.Validate(); err != nil {
+			return fmt.Errorf("embed %d: %w", i, err)
+		}
+		total += e.Length()
+	}
+	if total > EmbedTotalLimit {
+		return fmt.Errorf("embeds have %d characters, the limit is %d", total, EmbedTotalLimit)
+	}
+	return nil
+}
+
+// Validate reports the first Discord limit e exceeds.
+func (e Embed) Validate() error {
+	if err := checkLength("title", e.Title, EmbedTitleLimit); err != nil {
+		return err
+	}
+	if err := checkLength("description", e.Description, EmbedDescriptionLimit); err != nil {
+		return err
+	}
+	if e.Footer != nil {
+		if err := checkLength("footer text", e.Footer.Text, EmbedFooterTextLimit); err != nil {
+			return err
+		}
+	}
+	if e.Author != nil {
+		if err := checkLength("author name", e.Author.Name, EmbedAuthorNameLimit); err != nil {
+			return err
+		}
+	}
+	if len(e.Fields) > EmbedFieldsLimit {
+		return fmt.Errorf("embed has %d fields, the limit is %d", len(e.Fields), EmbedFieldsLimit)
+	}
+	for _, f := range e.Fields {
+		if err := checkLength("field name", f.Name, EmbedFieldNameLimit); err != nil {
+			return err
+		}
+		if err := checkLength("field value", f.Value, EmbedFieldValueLimit); err != nil {
+			return err
+		}
+	}
+	if n := e.Length(); n > EmbedTotalLimit {
+		return fmt.Errorf("embed has %d characters, the limit is %d", n, EmbedTotalLimit)
+	}
+	return nil
+}
+
+func checkLength(name, value string, limit int) error {
+	if n := utf8.RuneCountInString(value); n > limit {
+		return fmt.Errorf("%s has %d characters, the limit is %d", name, n, limit)
+	}
+	return nil
+}
+
+// FitMessage truncates msg to fit into a single message. Embeds are split
+// like SplitEmbed but only the parts that fit are kept, fields past the
+// limits are dropped.
+func FitMessage(msg WebhookMessage) WebhookMessage {
+	if msg.Validate() == nil {
+		return msg
+	}
+
+	msg.Content = Truncate(msg.Content, MessageContentLimit)
+
+	var fitted []Embed
+	for _, e := range msg.Embeds {
+		parts := SplitEmbed(e)
+		first := parts[0]
+		if last := parts[len(parts)-1]; len(parts) > 1 {
+			first.Footer, first.Image, first.Timestamp = last.Footer, last.Image, last.Timestamp
+		}
+		fitted = append(fitted, first)
+	}
+	if groups := GroupEmbeds(fitted); len(groups) > 0 {
+		fitted = groups[0]
+	}
+	msg.Embeds = fitted
+	return msg
+}
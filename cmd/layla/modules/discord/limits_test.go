@@ -0,0 +1,206 @@
+package discord
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		limit int
+		want  string
+	}{
+		{"short", "abc", 5, "abc"},
+		{"at limit", "abcde", 5, "abcde"},
+		{"over limit", "abcdef", 5, "abcd…"},
+		{"multi-byte at limit", "zażółć", 6, "zażółć"},
+		{"multi-byte over limit", "zażółć gęślą", 6, "zażół…"},
+		{"emoji", "🍕🍕🍕", 2, "🍕…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Truncate(tt.s, tt.limit)
+			if got != tt.want {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.limit, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("Truncate(%q, %d) = %q is not valid UTF-8", tt.s, tt.limit, got)
+			}
+		})
+	}
+}
+
+func TestEmbedValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		embed   Embed
+		wantErr bool
+	}{
+		{"title at limit", Embed{Title: strings.Repeat("t", EmbedTitleLimit)}, false},
+		{"title over limit", Embed{Title: strings.Repeat("t", EmbedTitleLimit+1)}, true},
+		{"multi-byte title at limit", Embed{Title: strings.Repeat("ż", EmbedTitleLimit)}, false},
+		{"description at limit", Embed{Description: strings.Repeat("d", EmbedDescriptionLimit)}, false},
+		{"description over limit", Embed{Description: strings.Repeat("d", EmbedDescriptionLimit+1)}, true},
+		{"field value at limit", Embed{Fields: []EmbedField{{Name: "n", Value: strings.Repeat("v", EmbedFieldValueLimit)}}}, false},
+		{"field value over limit", Embed{Fields: []EmbedField{{Name: "n", Value: strings.Repeat("v", EmbedFieldValueLimit+1)}}}, true},
+		{"fields at limit", Embed{Fields: make([]EmbedField, EmbedFieldsLimit)}, false},
+		{"fields over limit", Embed{Fields: make([]EmbedField, EmbedFieldsLimit+1)}, true},
+		{"total at limit", embedOfLength(EmbedTotalLimit), false},
+		{"total over limit", embedOfLength(EmbedTotalLimit + 1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.embed.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhookMessageValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     WebhookMessage
+		wantErr bool
+	}{
+		{"content at limit", WebhookMessage{Content: strings.Repeat("c", MessageContentLimit)}, false},
+		{"content over limit", WebhookMessage{Content: strings.Repeat("c", MessageContentLimit+1)}, true},
+		{"embeds at total limit", WebhookMessage{Embeds: []Embed{embedOfLength(3000), embedOfLength(3000)}}, false},
+		{"embeds over total limit", WebhookMessage{Embeds: []Embed{embedOfLength(3000), embedOfLength(3001)}}, true},
+		{"embeds at count limit", WebhookMessage{Embeds: make([]Embed, EmbedsPerMessageLimit)}, false},
+		{"embeds over count limit", WebhookMessage{Embeds: make([]Embed, EmbedsPerMessageLimit+1)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.msg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitEmbed(t *testing.T) {
+	tests := []struct {
+		name       string
+		embed      Embed
+		wantEmbeds int
+	}{
+		// The footer counts towards the total.
+		{"at total limit", embedOfLength(EmbedTotalLimit - len("footer")), 1},
+		{"over total limit", embedOfLength(EmbedTotalLimit - len("footer") + 1), 2},
+		{"fields at limit", Embed{Title: "t", Fields: fields(EmbedFieldsLimit, 1)}, 1},
+		{"fields over limit", Embed{Title: "t", Fields: fields(EmbedFieldsLimit+1, 1)}, 2},
+		// Five full fields fill 5125 characters, the sixth would go over 6000.
+		{"fields over total limit", Embed{Fields: fields(6, EmbedFieldValueLimit)}, 2},
+		{"oversized texts", Embed{Title: strings.Repeat("t", EmbedTitleLimit+1), Description: strings.Repeat("d", EmbedDescriptionLimit+1)}, 1},
+		{"empty field", Embed{Fields: []EmbedField{{}}}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.embed.Footer = &EmbedFooter{Text: "footer"}
+			embeds := SplitEmbed(tt.embed)
+			if len(embeds) != tt.wantEmbeds {
+				t.Fatalf("SplitEmbed() returned %d embeds, want %d", len(embeds), tt.wantEmbeds)
+			}
+
+			var nFields int
+			for i, e := range embeds {
+				if err := e.Validate(); err != nil {
+					t.Errorf("embed %d: %v", i, err)
+				}
+				if e.Footer != nil && i != len(embeds)-1 {
+					t.Errorf("embed %d has the footer, want it on the last one", i)
+				}
+				nFields += len(e.Fields)
+			}
+			if nFields != len(tt.embed.Fields) {
+				t.Errorf("SplitEmbed() kept %d fields, want %d", nFields, len(tt.embed.Fields))
+			}
+			if embeds[len(embeds)-1].Footer == nil {
+				t.Error("last embed has no footer")
+			}
+		})
+	}
+}
+
+func TestGroupEmbeds(t *testing.T) {
+	tests := []struct {
+		name   string
+		embeds []Embed
+		want   []int
+	}{
+		{"none", nil, nil},
+		{"at total limit", []Embed{embedOfLength(2000), embedOfLength(2000), embedOfLength(2000)}, []int{3}},
+		{"over total limit", []Embed{embedOfLength(2000), embedOfLength(2000), embedOfLength(2001)}, []int{2, 1}},
+		{"at count limit", make([]Embed, EmbedsPerMessageLimit), []int{EmbedsPerMessageLimit}},
+		{"over count limit", make([]Embed, EmbedsPerMessageLimit+1), []int{EmbedsPerMessageLimit, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := GroupEmbeds(tt.embeds)
+			var got []int
+			for _, g := range groups {
+				got = append(got, len(g))
+				if err := (WebhookMessage{Embeds: g}).Validate(); err != nil {
+					t.Errorf("group %v: %v", got, err)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("GroupEmbeds() group sizes = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("GroupEmbeds() group sizes = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFitMessage(t *testing.T) {
+	msg := WebhookMessage{
+		Content: strings.Repeat("c", MessageContentLimit+1),
+		Embeds: []Embed{
+			{Title: "first", Fields: fields(10, EmbedFieldValueLimit), Footer: &EmbedFooter{Text: "footer"}},
+			embedOfLength(3000),
+		},
+	}
+
+	fitted := FitMessage(msg)
+	if err := fitted.Validate(); err != nil {
+		t.Fatalf("FitMessage() result: %v", err)
+	}
+	if len(fitted.Embeds) == 0 || fitted.Embeds[0].Footer == nil {
+		t.Error("FitMessage() dropped the footer of the first embed")
+	}
+
+	// A message within the limits is left alone.
+	small := WebhookMessage{Content: "hi", Embeds: []Embed{embedOfLength(10)}}
+	if got := FitMessage(small); got.Content != "hi" || len(got.Embeds) != 1 || got.Embeds[0].Length() != 10 {
+		t.Errorf("FitMessage() changed a message within the limits: %+v", got)
+	}
+}
+
+// embedOfLength returns an embed Discord counts as n characters long.
+func embedOfLength(n int) Embed {
+	e := Embed{Description: strings.Repeat("d", min(n, EmbedDescriptionLimit))}
+	for n -= utf8.RuneCountInString(e.Description); n > 0; n -= EmbedFieldValueLimit + 1 {
+		e.Fields = append(e.Fields, EmbedField{Name: "n", Value: strings.Repeat("v", min(n-1, EmbedFieldValueLimit))})
+	}
+	return e
+}
+
+// fields returns n fields with one-character names and values of length
+// valueLen.
+func fields(n, valueLen int) []EmbedField {
+	result := make([]EmbedField, n)
+	for i := range result {
+		result[i] = EmbedField{Name: "n", Value: strings.Repeat("v", valueLen)}
+	}
+	return result
+}
@@ -0,0 +1,231 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	DefaultPromptTimeout = 5 * time.Minute
+	maxChoices           = 25
+	buttonsPerRow        = 5
+)
+
+type Choice struct {
+	Label string
+	Value string
+	// Style defaults to a secondary button.
+	Style discordgo.ButtonStyle
+	Emoji string
+}
+
+// Prompt is a message with a button per choice.
+type Prompt struct {
+	Content string
+	Embeds  []*discordgo.MessageEmbed
+	Choices []Choice
+	// UserID restricts the answer to one user, others get an ephemeral note.
+	UserID  string
+	Timeout time.Duration
+}
+
+// Ask sends the prompt to a channel and waits for a choice. The buttons are
+// disabled once somebody answers or when the prompt times out with
+// ErrTimeout. The returned interaction has already been acknowledged by
+// updating the prompt, further responses have to be followups.
+func (in *Interactions) Ask(ctx context.Context, channelID string, p Prompt) (string, *discordgo.InteractionCreate, error) {
+	if len(p.Choices) == 0 || len(p.Choices) > maxChoices {
+		return "", nil, fmt.Errorf("discord: a prompt needs 1 to %d choices, got %d", maxChoices, len(p.Choices))
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = DefaultPromptTimeout
+	}
+
+	w := in.NewWaiter()
+	defer w.Close()
+
+	rows, err := choiceRows(w, p.Choices, -1)
+	if err != nil {
+		return "", nil, err
+	}
+
+	msg, err := in.session.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:    p.Content,
+		Embeds:     p.Embeds,
+		Components: rows,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("discord: can't send prompt: %w", err)
+	}
+
+	deadline := time.Now().Add(p.Timeout)
+	for {
+		e, err := w.Next(ctx, time.Until(deadline))
+		if err != nil {
+			disabled := DisableComponents(rows)
+			// The prompt is closed even when ctx is done.
+			in.session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+				ID:         msg.ID,
+				Channel:    msg.ChannelID,
+				Components: &disabled,
+			})
+			return "", nil, err
+		}
+
+		if p.UserID != "" && InteractionUser(e.Interaction).ID != p.UserID {
+			in.session.InteractionRespond(e.Interaction.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: "This prompt is not for you.",
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			continue
+		}
+
+		index := -1
+		if len(e.State) > 0 {
+			index, _ = strconv.Atoi(e.State[0])
+		}
+		if index < 0 || index >= len(p.Choices) {
+			continue
+		}
+
+		answered, err := choiceRows(w, p.Choices, index)
+		if err != nil {
+			return "", nil, err
+		}
+		err = in.session.InteractionRespond(e.Interaction.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Content:    p.Content,
+				Embeds:     p.Embeds,
+				Components: DisableComponents(answered),
+			},
+		})
+		if err != nil {
+			return "", nil, fmt.Errorf("discord: can't acknowledge prompt answer: %w", err)
+		}
+		return p.Choices[index].Value, e.Interaction, nil
+	}
+}
+
+// Confirm asks a yes or no question, a timeout counts as no.
+func (in *Interactions) Confirm(ctx context.Context, channelID, userID, question string, timeout time.Duration) (bool, error) {
+	value, _, err := in.Ask(ctx, channelID, Prompt{
+		Content: question,
+		Choices: []Choice{
+			{Label: "Yes", Value: "yes", Style: discordgo.SuccessButton},
+			{Label: "No", Value: "no", Style: discordgo.DangerButton},
+		},
+		UserID:  userID,
+		Timeout: timeout,
+	})
+	if errors.Is(err, ErrTimeout) {
+		return false, nil
+	}
+	return value == "yes", err
+}
+
+// choiceRows lays the choices out as buttons, the chosen one, if any, is
+// highlighted.
+func choiceRows(w *Waiter, choices []Choice, chosen int) ([]discordgo.MessageComponent, error) {
+	var rows []discordgo.MessageComponent
+	var row discordgo.ActionsRow
+	for i, choice := range choices {
+		customID, err := w.CustomID(strconv.Itoa(i))
+		if err != nil {
+			return nil, err
+		}
+
+		button := discordgo.Button{
+			Label:    Truncate(choice.Label, 80),
+			Style:    choice.Style,
+			CustomID: customID,
+		}
+		if button.Style == 0 {
+			button.Style = discordgo.SecondaryButton
+		}
+		if chosen >= 0 {
+			button.Style = discordgo.SecondaryButton
+			if i == chosen {
+				button.Style = discordgo.PrimaryButton
+			}
+		}
+		if choice.Emoji != "" {
+			button.Emoji = &discordgo.ComponentEmoji{Name: choice.Emoji}
+		}
+
+		row.Components = append(row.Components, button)
+		if len(row.Components) == buttonsPerRow {
+			rows = append(rows, row)
+			row = discordgo.ActionsRow{}
+		}
+	}
+	if len(row.Components) > 0 {
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Modal is a form of text inputs, every input needs a custom ID.
+type Modal struct {
+	Title   string
+	Inputs  []discordgo.TextInput
+	Timeout time.Duration
+}
+
+// AskModal responds to i with a modal and waits until it is submitted by the
+// same user. The values are keyed by the input custom IDs. The caller has to
+// respond to the returned submit interaction.
+func (in *Interactions) AskModal(ctx context.Context, i *discordgo.InteractionCreate, m Modal) (map[string]string, *discordgo.InteractionCreate, error) {
+	if m.Timeout <= 0 {
+		m.Timeout = DefaultPromptTimeout
+	}
+
+	w := in.NewWaiter()
+	defer w.Close()
+
+	customID, err := w.CustomID()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows []discordgo.MessageComponent
+	for _, input := range m.Inputs {
+		if input.Style == 0 {
+			input.Style = discordgo.TextInputShort
+		}
+		rows = append(rows, discordgo.ActionsRow{Components: []discordgo.MessageComponent{input}})
+	}
+
+	err = in.session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID:   customID,
+			Title:      Truncate(m.Title, 45),
+			Components: rows,
+		},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("discord: can't open modal: %w", err)
+	}
+
+	userID := InteractionUser(i).ID
+	deadline := time.Now().Add(m.Timeout)
+	for {
+		e, err := w.Next(ctx, time.Until(deadline))
+		if err != nil {
+			return nil, nil, err
+		}
+		if e.Interaction.Type != discordgo.InteractionModalSubmit || InteractionUser(e.Interaction).ID != userID {
+			continue
+		}
+		return ModalValues(e.Interaction), e.Interaction, nil
+	}
+}
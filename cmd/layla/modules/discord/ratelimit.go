@@ -0,0 +1,130 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxRateLimitRetries is how often a request is retried after a 429 before
+// giving up.
+const maxRateLimitRetries = 5
+
+type rateLimitBucket struct {
+	remaining int
+	reset     time.Time
+}
+
+// rateLimiter tracks the X-RateLimit-* buckets Discord reports per route and
+// the global limit, so requests wait instead of running into 429s.
+type rateLimiter struct {
+	mu      sync.Mutex
+	routes  map[string]string
+	buckets map[string]*rateLimitBucket
+	global  time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		routes:  make(map[string]string),
+		buckets: make(map[string]*rateLimitBucket),
+	}
+}
+
+// delay returns how long a request on route has to wait.
+func (l *rateLimiter) delay(route string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	reset := l.global
+	if b, ok := l.buckets[l.routes[route]]; ok && b.remaining <= 0 && b.reset.After(reset) {
+		reset = b.reset
+	}
+	return reset.Sub(now)
+}
+
+func (l *rateLimiter) wait(ctx context.Context, route string) error {
+	return sleep(ctx, l.delay(route))
+}
+
+// update records the bucket state from the response headers.
+func (l *rateLimiter) update(route string, h http.Header) {
+	bucketID := h.Get("X-RateLimit-Bucket")
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if bucketID == "" || err != nil {
+		return
+	}
+	resetAfter, err := strconv.ParseFloat(h.Get("X-RateLimit-Reset-After"), 64)
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.routes[route] = bucketID
+	l.buckets[bucketID] = &rateLimitBucket{
+		remaining: remaining,
+		reset:     time.Now().Add(seconds(resetAfter)),
+	}
+}
+
+// limited handles a 429 response and returns how long to wait before
+// retrying.
+func (l *rateLimiter) limited(route string, h http.Header, body []byte) time.Duration {
+	var payload struct {
+		RetryAfter float64 `json:"retry_after"`
+		Global     bool    `json:"global"`
+	}
+	json.Unmarshal(body, &payload)
+
+	retryAfter := seconds(payload.RetryAfter)
+	if retryAfter <= 0 {
+		if v, err := strconv.ParseFloat(h.Get("Retry-After"), 64); err == nil {
+			retryAfter = seconds(v)
+		}
+	}
+	if retryAfter <= 0 {
+		retryAfter = time.Second
+	}
+
+	global := payload.Global || h.Get("X-RateLimit-Global") == "true"
+	reset := time.Now().Add(retryAfter)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if global {
+		l.global = reset
+		return retryAfter
+	}
+
+	bucketID := h.Get("X-RateLimit-Bucket")
+	if bucketID == "" {
+		bucketID = route
+	}
+	l.routes[route] = bucketID
+	l.buckets[bucketID] = &rateLimitBucket{reset: reset}
+	return retryAfter
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,125 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterLimited(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     http.Header
+		body       string
+		want       time.Duration
+		wantGlobal bool
+	}{
+		{"Retry-After header", http.Header{"Retry-After": {"2"}}, "", 2 * time.Second, false},
+		{"fractional Retry-After header", http.Header{"Retry-After": {"0.5"}}, "", 500 * time.Millisecond, false},
+		{"retry_after body", nil, `{"retry_after": 1.5}`, 1500 * time.Millisecond, false},
+		{"body before header", http.Header{"Retry-After": {"2"}}, `{"retry_after": 0.25}`, 250 * time.Millisecond, false},
+		{"without Retry-After", nil, "", time.Second, false},
+		{"invalid Retry-After", http.Header{"Retry-After": {"soon"}}, "not json", time.Second, false},
+		{"global body", nil, `{"retry_after": 3, "global": true}`, 3 * time.Second, true},
+		{"global header", http.Header{"Retry-After": {"3"}, "X-Ratelimit-Global": {"true"}}, "", 3 * time.Second, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newRateLimiter()
+			got := l.limited("POST", tt.header, []byte(tt.body))
+			if got != tt.want {
+				t.Errorf("limited() = %s, want %s", got, tt.want)
+			}
+
+			// The route waits either way, other routes only for a global limit.
+			if d := l.delay("POST"); d <= 0 || d > tt.want {
+				t.Errorf("delay() on the limited route = %s, want (0, %s]", d, tt.want)
+			}
+			if d := l.delay("PATCH /messages"); (d > 0) != tt.wantGlobal {
+				t.Errorf("delay() on another route = %s, want waiting %v", d, tt.wantGlobal)
+			}
+		})
+	}
+}
+
+func TestRateLimiterUpdate(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   http.Header
+		wantWait bool
+	}{
+		{"remaining", bucketHeader("1", "5"), false},
+		{"exhausted", bucketHeader("0", "5"), true},
+		{"no bucket", http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset-After": {"5"}}, false},
+		{"invalid reset", bucketHeader("0", "later"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newRateLimiter()
+			l.update("POST", tt.header)
+			if d := l.delay("POST"); (d > 0) != tt.wantWait {
+				t.Errorf("delay() = %s, want waiting %v", d, tt.wantWait)
+			}
+		})
+	}
+}
+
+func bucketHeader(remaining, resetAfter string) http.Header {
+	return http.Header{
+		"X-Ratelimit-Bucket":      {"abc"},
+		"X-Ratelimit-Remaining":   {remaining},
+		"X-Ratelimit-Reset-After": {resetAfter},
+	}
+}
+
+func TestWebhookRetriesAfter429(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch requests.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "0.01")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			// Without Retry-After the body says how long to wait.
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"message": "You are being rate limited.", "retry_after": 0.01, "global": false}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewWebhookClient(srv.URL)
+	if _, err := c.Send(context.Background(), WebhookMessage{Content: "test"}, SendOptions{}); err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("server got %d requests, want 3", n)
+	}
+}
+
+func TestWebhookGivesUpAfter429s(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "0.001")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c := NewWebhookClient(srv.URL)
+	_, err := c.Send(context.Background(), WebhookMessage{Content: "test"}, SendOptions{})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Send() = %v, want a 429 APIError", err)
+	}
+	if n := requests.Load(); n != maxRateLimitRetries+1 {
+		t.Errorf("server got %d requests, want %d", n, maxRateLimitRetries+1)
+	}
+}
@@ -1,8 +1,12 @@
 package discord
 
 import (
+	"context"
 	"fmt"
+	"time"
+
 	"git.jakub.app/jakub/X/internal/env"
+	"github.com/rs/zerolog/log"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -11,6 +15,8 @@ var (
 	DISCORD_TOKEN = env.GetEnv("DISCORD_TOKEN", "")
 )
 
+const maxOpenBackoff = 2 * time.Minute
+
 type Discord struct {
 	dg *discordgo.Session
 }
@@ -21,6 +27,19 @@ func New() (*Discord, error) {
 		return nil, fmt.Errorf("discord: error creating discord session: %w", err)
 	}
 
+	// discordgo resumes or reconnects on its own when the gateway connection
+	// drops.
+	dg.ShouldReconnectOnError = true
+	dg.AddHandler(func(s *discordgo.Session, _ *discordgo.Connect) {
+		log.Info().Msg("discord: connected to gateway")
+	})
+	dg.AddHandler(func(s *discordgo.Session, _ *discordgo.Disconnect) {
+		log.Warn().Msg("discord: disconnected from gateway, reconnecting")
+	})
+	dg.AddHandler(func(s *discordgo.Session, _ *discordgo.Resumed) {
+		log.Info().Msg("discord: gateway session resumed")
+	})
+
 	return &Discord{
 		dg: dg,
 	}, nil
@@ -29,3 +48,25 @@ func New() (*Discord, error) {
 func (d *Discord) Session() *discordgo.Session {
 	return d.dg
 }
+
+// Open connects to the gateway. A failed attempt is retried with exponential
+// backoff until ctx is done.
+func (d *Discord) Open(ctx context.Context) error {
+	backoff := time.Second
+	for {
+		err := d.dg.Open()
+		if err == nil {
+			return nil
+		}
+		log.Error().Err(err).Dur("retryIn", backoff).Msg("discord: can't open gateway connection")
+
+		if err := sleep(ctx, backoff); err != nil {
+			return fmt.Errorf("discord: error opening gateway connection: %w", err)
+		}
+		backoff = min(backoff*2, maxOpenBackoff)
+	}
+}
+
+func (d *Discord) Close() error {
+	return d.dg.Close()
+}
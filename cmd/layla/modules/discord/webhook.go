@@ -2,16 +2,29 @@ package discord
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 )
 
 type Embed struct {
-	Title       string       `json:"title,omitempty"`
-	Description string       `json:"description,omitempty"`
-	Color       int          `json:"color,omitempty"`
-	Fields      []EmbedField `json:"fields,omitempty"`
+	Title       string          `json:"title,omitempty"`
+	Description string          `json:"description,omitempty"`
+	URL         string          `json:"url,omitempty"`
+	Timestamp   *time.Time      `json:"timestamp,omitempty"`
+	Color       int             `json:"color,omitempty"`
+	Footer      *EmbedFooter    `json:"footer,omitempty"`
+	Image       *EmbedImage     `json:"image,omitempty"`
+	Thumbnail   *EmbedThumbnail `json:"thumbnail,omitempty"`
+	Author      *EmbedAuthor    `json:"author,omitempty"`
+	Fields      []EmbedField    `json:"fields,omitempty"`
 }
 
 type EmbedField struct {
@@ -20,31 +33,314 @@ type EmbedField struct {
 	Inline bool   `json:"inline,omitempty"`
 }
 
+type EmbedFooter struct {
+	Text    string `json:"text"`
+	IconURL string `json:"icon_url,omitempty"`
+}
+
+type EmbedImage struct {
+	URL string `json:"url"`
+}
+
+type EmbedThumbnail struct {
+	URL string `json:"url"`
+}
+
+type EmbedAuthor struct {
+	Name    string `json:"name"`
+	URL     string `json:"url,omitempty"`
+	IconURL string `json:"icon_url,omitempty"`
+}
+
 type WebhookWithEmbed struct {
 	Content string  `json:"content,omitempty"`
 	Embeds  []Embed `json:"embeds,omitempty"`
+	// Files are uploaded as attachments.
+	Files []File `json:"-"`
 }
 
-func SendMessageWithEmbed(webhookURL string, content string, embed Embed) error {
-	webhook := WebhookWithEmbed{
-		Content: content,
-		Embeds:  []Embed{embed},
+// WebhookMessage is the body of an execute or edit webhook request.
+type WebhookMessage struct {
+	Content string `json:"content,omitempty"`
+	// Username and AvatarURL override the webhook defaults for this message.
+	Username  string  `json:"username,omitempty"`
+	AvatarURL string  `json:"avatar_url,omitempty"`
+	TTS       bool    `json:"tts,omitempty"`
+	Embeds    []Embed `json:"embeds,omitempty"`
+	// ThreadName creates a new thread when posting to a forum channel.
+	ThreadName string `json:"thread_name,omitempty"`
+	// Files are uploaded as attachments. On edit they replace the existing
+	// attachments.
+	Files []File `json:"-"`
+}
+
+// Message is a message sent by a webhook, returned when waiting for it.
+type Message struct {
+	ID              string       `json:"id"`
+	ChannelID       string       `json:"channel_id"`
+	WebhookID       string       `json:"webhook_id"`
+	Content         string       `json:"content"`
+	Embeds          []Embed      `json:"embeds"`
+	Attachments     []Attachment `json:"attachments"`
+	Timestamp       time.Time    `json:"timestamp"`
+	EditedTimestamp *time.Time   `json:"edited_timestamp"`
+}
+
+// SendOptions control a single execute webhook request.
+type SendOptions struct {
+	// Wait makes Discord confirm the message and return it, otherwise Send
+	// returns a nil message.
+	Wait bool
+	// ThreadID posts into an existing thread of the webhook's channel.
+	ThreadID string
+}
+
+// APIError is an error response of the Discord API.
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("discord: unexpected status code: %d", e.StatusCode)
 	}
+	return fmt.Sprintf("discord: %s (status %d, code %d)", e.Message, e.StatusCode, e.Code)
+}
 
-	jsonData, err := json.Marshal(webhook)
-	if err != nil {
-		return fmt.Errorf("failed to marshal webhook data: %w", err)
+// WebhookClient sends, edits and deletes messages of a single webhook.
+// Requests are sent one at a time in the order they were made and wait for
+// the Discord rate limits, a 429 is retried after Retry-After.
+type WebhookClient struct {
+	url        string
+	httpClient *http.Client
+	limiter    *rateLimiter
+	// queue serializes requests.
+	queue sync.Mutex
+}
+
+func NewWebhookClient(webhookURL string) *WebhookClient {
+	return &WebhookClient{
+		url:        strings.TrimSuffix(webhookURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		limiter:    newRateLimiter(),
+	}
+}
+
+// webhookClients shares clients, and so their rate limit state, between
+// SendMessageWithEmbeds calls.
+var webhookClients sync.Map
+
+func webhookClient(webhookURL string) *WebhookClient {
+	if c, ok := webhookClients.Load(webhookURL); ok {
+		return c.(*WebhookClient)
+	}
+	c, _ := webhookClients.LoadOrStore(webhookURL, NewWebhookClient(webhookURL))
+	return c.(*WebhookClient)
+}
+
+// Send executes the webhook with msg in a single message. Content and embeds
+// over the Discord limits are truncated, use SendEmbeds to split them into
+// several messages instead.
+func (c *WebhookClient) Send(ctx context.Context, msg WebhookMessage, opts SendOptions) (*Message, error) {
+	msg = FitMessage(msg)
+
+	query := url.Values{}
+	if opts.Wait {
+		query.Set("wait", "true")
+	}
+	if opts.ThreadID != "" {
+		query.Set("thread_id", opts.ThreadID)
+	}
+
+	var message *Message
+	if opts.Wait {
+		message = &Message{}
+	}
+	if err := c.doMessage(ctx, http.MethodPost, "", query, msg, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// SendEmbeds splits the embeds to fit the Discord limits and sends them in
+// as many messages as needed. The content goes with the first one and the
+// other fields of msg with every message. Files go with the message whose
+// embeds reference them, the others with the first one.
+func (c *WebhookClient) SendEmbeds(ctx context.Context, msg WebhookMessage, embeds []Embed, opts SendOptions) ([]*Message, error) {
+	var split []Embed
+	for _, embed := range embeds {
+		split = append(split, SplitEmbed(embed)...)
+	}
+
+	groups := GroupEmbeds(split)
+	if len(groups) == 0 {
+		groups = [][]Embed{nil}
+	}
+
+	referenced := make(map[string]bool)
+	for _, f := range referencedFiles(split, msg.Files) {
+		referenced[f.Name] = true
 	}
 
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(jsonData))
+	var messages []*Message
+	for i, group := range groups {
+		part := msg
+		part.Embeds = group
+		part.Files = referencedFiles(group, msg.Files)
+		if i == 0 {
+			for _, f := range msg.Files {
+				if !referenced[f.Name] {
+					part.Files = append(part.Files, f)
+				}
+			}
+		} else {
+			part.Content = ""
+			part.TTS = false
+		}
+
+		message, err := c.Send(ctx, part, opts)
+		if err != nil {
+			return messages, err
+		}
+		if message != nil {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages, nil
+}
+
+// Edit replaces the content and embeds of a message sent by this webhook,
+// truncating them like Send. threadID is required for messages inside a
+// thread.
+func (c *WebhookClient) Edit(ctx context.Context, messageID string, msg WebhookMessage, threadID string) (*Message, error) {
+	message := &Message{}
+	if err := c.doMessage(ctx, http.MethodPatch, "/messages/"+url.PathEscape(messageID), threadQuery(threadID), FitMessage(msg), message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// Delete removes a message sent by this webhook.
+func (c *WebhookClient) Delete(ctx context.Context, messageID string, threadID string) error {
+	return c.do(ctx, http.MethodDelete, "/messages/"+url.PathEscape(messageID), threadQuery(threadID), nil, "", nil)
+}
+
+// redactURL drops the URL from transport errors, the webhook token is part of
+// it.
+func redactURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
+
+func threadQuery(threadID string) url.Values {
+	query := url.Values{}
+	if threadID != "" {
+		query.Set("thread_id", threadID)
+	}
+	return query
+}
+
+func (c *WebhookClient) doMessage(ctx context.Context, method, path string, query url.Values, msg WebhookMessage, out any) error {
+	data, contentType, err := encodeMessage(msg)
 	if err != nil {
-		return fmt.Errorf("failed to send webhook: %w", err)
+		return err
+	}
+	return c.do(ctx, method, path, query, data, contentType, out)
+}
+
+// do sends data and decodes the response into out when it is not nil. Any
+// 2xx status is a success.
+func (c *WebhookClient) do(ctx context.Context, method, path string, query url.Values, data []byte, contentType string, out any) error {
+
+	endpoint := c.url + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
 	}
+
+	// Messages share a bucket per webhook, so the route ignores the ID.
+	route := method
+	if path != "" {
+		route += " /messages"
+	}
+
+	c.queue.Lock()
+	defer c.queue.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.wait(ctx, route); err != nil {
+			return err
+		}
+
+		var reader io.Reader
+		if data != nil {
+			reader = bytes.NewReader(data)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+		if err != nil {
+			return fmt.Errorf("failed to create webhook request: %w", redactURL(err))
+		}
+		if data != nil {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to send webhook: %w", redactURL(err))
+		}
+		c.limiter.update(route, resp.Header)
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+
+			retryAfter := c.limiter.limited(route, resp.Header, respBody)
+			if attempt == maxRateLimitRetries {
+				return &APIError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("rate limited, retry after %s", retryAfter)}
+			}
+			continue
+		}
+
+		return decodeResponse(resp, out)
+	}
+}
+
+func decodeResponse(resp *http.Response, out any) error {
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		json.Unmarshal(data, apiErr)
+		return apiErr
 	}
 
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode webhook response: %w", err)
+		}
+	}
 	return nil
 }
+
+// SendMessageWithFiles sends the webhook message with its files attached.
+func SendMessageWithFiles(webhookURL string, webhook WebhookWithEmbed) error {
+	_, err := webhookClient(webhookURL).SendEmbeds(context.Background(), WebhookMessage{Content: webhook.Content, Files: webhook.Files}, webhook.Embeds, SendOptions{})
+	return err
+}
+
+func SendMessageWithEmbed(webhookURL string, content string, embed Embed) error {
+	return SendMessageWithEmbeds(webhookURL, content, []Embed{embed})
+}
+
+// SendMessageWithEmbeds splits the embeds to fit the Discord limits and sends
+// them in as many messages as needed. The content goes with the first one.
+func SendMessageWithEmbeds(webhookURL string, content string, embeds []Embed) error {
+	_, err := webhookClient(webhookURL).SendEmbeds(context.Background(), WebhookMessage{Content: content}, embeds, SendOptions{})
+	return err
+}
@@ -0,0 +1,180 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebhookErrorRedactsToken(t *testing.T) {
+	const token = "secret-webhook-token"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	baseURL := srv.URL
+	// A closed server makes the transport fail.
+	srv.Close()
+
+	c := NewWebhookClient(baseURL + "/api/webhooks/1/" + token)
+	_, err := c.Send(context.Background(), WebhookMessage{Content: "test"}, SendOptions{})
+	if err == nil {
+		t.Fatal("Send() succeeded against a closed server")
+	}
+	if strings.Contains(err.Error(), token) {
+		t.Errorf("error contains the token: %v", err)
+	}
+}
+
+// uploadedPart is a part of a multipart webhook request.
+type uploadedPart struct {
+	filename    string
+	contentType string
+	data        string
+}
+
+// multipartServer records the parts of every multipart request by form name.
+func multipartServer(t *testing.T, requests *[]map[string]uploadedPart) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "multipart/form-data" {
+			t.Errorf("Content-Type = %q, want multipart/form-data", r.Header.Get("Content-Type"))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mr, err := r.MultipartReader()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		parts := make(map[string]uploadedPart)
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			data, _ := io.ReadAll(part)
+			parts[part.FormName()] = uploadedPart{
+				filename:    part.FileName(),
+				contentType: part.Header.Get("Content-Type"),
+				data:        string(data),
+			}
+		}
+		*requests = append(*requests, parts)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
+
+func TestWebhookUploadsFiles(t *testing.T) {
+	var requests []map[string]uploadedPart
+	srv := multipartServer(t, &requests)
+	defer srv.Close()
+
+	msg := WebhookMessage{
+		Content: "menu",
+		Embeds:  []Embed{{Title: "Chart", Image: &EmbedImage{URL: AttachmentURL("chart.png")}}},
+		Files: []File{
+			{Name: "chart.png", ContentType: "image/png", Description: "Weekly chart", Data: []byte("png data")},
+			{Name: `menu "week".txt`, Data: []byte("text data")},
+		},
+	}
+	c := NewWebhookClient(srv.URL)
+	if _, err := c.Send(context.Background(), msg, SendOptions{}); err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("server got %d requests, want 1", len(requests))
+	}
+	parts := requests[0]
+
+	payloadPart, ok := parts["payload_json"]
+	if !ok {
+		t.Fatal("request has no payload_json part")
+	}
+	if payloadPart.contentType != "application/json" {
+		t.Errorf("payload_json Content-Type = %q, want application/json", payloadPart.contentType)
+	}
+	var payload struct {
+		Content     string              `json:"content"`
+		Embeds      []Embed             `json:"embeds"`
+		Attachments []attachmentPayload `json:"attachments"`
+	}
+	if err := json.Unmarshal([]byte(payloadPart.data), &payload); err != nil {
+		t.Fatalf("payload_json: %v", err)
+	}
+	if payload.Content != "menu" || len(payload.Embeds) != 1 || payload.Embeds[0].Image.URL != "attachment://chart.png" {
+		t.Errorf("payload_json = %s, want the message content and embed", payloadPart.data)
+	}
+	wantAttachments := []attachmentPayload{
+		{ID: 0, Filename: "chart.png", Description: "Weekly chart"},
+		{ID: 1, Filename: `menu "week".txt`},
+	}
+	if len(payload.Attachments) != len(wantAttachments) {
+		t.Fatalf("payload_json attachments = %+v, want %+v", payload.Attachments, wantAttachments)
+	}
+	for i, want := range wantAttachments {
+		if payload.Attachments[i] != want {
+			t.Errorf("attachment %d = %+v, want %+v", i, payload.Attachments[i], want)
+		}
+	}
+
+	tests := []struct {
+		name string
+		want uploadedPart
+	}{
+		{"files[0]", uploadedPart{filename: "chart.png", contentType: "image/png", data: "png data"}},
+		{"files[1]", uploadedPart{filename: `menu "week".txt`, contentType: "application/octet-stream", data: "text data"}},
+	}
+	for _, tt := range tests {
+		if got := parts[tt.name]; got != tt.want {
+			t.Errorf("%s = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookSendEmbedsSplitsFiles(t *testing.T) {
+	var requests []map[string]uploadedPart
+	srv := multipartServer(t, &requests)
+	defer srv.Close()
+
+	// The embeds don't fit one message, the second file belongs to the
+	// second one and the unreferenced file goes with the first.
+	embeds := []Embed{
+		{Title: "first", Description: strings.Repeat("a", 4000), Image: &EmbedImage{URL: AttachmentURL("first.png")}},
+		{Title: "second", Description: strings.Repeat("b", 4000), Image: &EmbedImage{URL: AttachmentURL("second.png")}},
+	}
+	files := []File{
+		{Name: "first.png", Data: []byte("1")},
+		{Name: "second.png", Data: []byte("2")},
+		{Name: "log.txt", Data: []byte("log")},
+	}
+	c := NewWebhookClient(srv.URL)
+	if _, err := c.SendEmbeds(context.Background(), WebhookMessage{Files: files}, embeds, SendOptions{}); err != nil {
+		t.Fatalf("SendEmbeds() = %v", err)
+	}
+
+	want := [][]string{{"first.png", "log.txt"}, {"second.png"}}
+	if len(requests) != len(want) {
+		t.Fatalf("server got %d requests, want %d", len(requests), len(want))
+	}
+	for i, names := range want {
+		if len(requests[i]) != len(names)+1 {
+			t.Errorf("request %d has %d parts, want payload_json and %v", i, len(requests[i]), names)
+		}
+		for n, name := range names {
+			part := requests[i][fmt.Sprintf("files[%d]", n)]
+			if part.filename != name {
+				t.Errorf("request %d files[%d] = %q, want %q", i, n, part.filename, name)
+			}
+		}
+	}
+}
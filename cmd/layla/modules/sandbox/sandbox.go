@@ -0,0 +1,199 @@
+// Package sandbox runs untrusted code snippets in a subprocess with CPU,
+// memory, file size and time limits, without network access and on a
+// read-only view of the system directories where only a temporary work
+// directory is writable. It is only supported on Linux with unprivileged
+// user namespaces.
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var ErrUnsupported = errors.New("sandbox: not supported on this platform")
+
+// workDir is where a run's work directory is mounted in the sandbox.
+const workDir = "/tmp"
+
+type Config struct {
+	// Python is the interpreter used by RunPython.
+	Python string
+	// Timeout is the wall clock limit of a run.
+	Timeout time.Duration
+	CPUTime time.Duration
+	// MemoryBytes limits the address space of every process of a run.
+	MemoryBytes int64
+	// FileBytes limits the size of every file written by a run.
+	FileBytes int64
+	// OutputBytes limits the captured stdout and stderr, each.
+	OutputBytes int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Python:      "python3",
+		Timeout:     10 * time.Second,
+		CPUTime:     5 * time.Second,
+		MemoryBytes: 256 << 20,
+		FileBytes:   8 << 20,
+		OutputBytes: 16 << 10,
+	}
+}
+
+type Result struct {
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	ExitCode int    `json:"exitCode"`
+	TimedOut bool   `json:"timedOut,omitempty"`
+	// Truncated is set when stdout or stderr went over OutputBytes.
+	Truncated bool          `json:"truncated,omitempty"`
+	Duration  time.Duration `json:"duration"`
+}
+
+type Sandbox struct {
+	config Config
+	python string
+}
+
+// New checks that the platform is supported and the interpreter runs in the
+// sandbox. Zero fields of config are taken from DefaultConfig.
+func New(config Config) (*Sandbox, error) {
+	def := DefaultConfig()
+	if config.Python == "" {
+		config.Python = def.Python
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = def.Timeout
+	}
+	if config.CPUTime <= 0 {
+		config.CPUTime = def.CPUTime
+	}
+	if config.MemoryBytes <= 0 {
+		config.MemoryBytes = def.MemoryBytes
+	}
+	if config.FileBytes <= 0 {
+		config.FileBytes = def.FileBytes
+	}
+	if config.OutputBytes <= 0 {
+		config.OutputBytes = def.OutputBytes
+	}
+
+	if err := supported(); err != nil {
+		return nil, err
+	}
+
+	python, err := exec.LookPath(config.Python)
+	if err != nil {
+		return nil, fmt.Errorf("sandbox: %w", err)
+	}
+
+	s := &Sandbox{config: config, python: python}
+	result, err := s.RunPython(context.Background(), "pass")
+	if err != nil {
+		return nil, fmt.Errorf("sandbox: are user namespaces available? %w", err)
+	}
+	if result.ExitCode != 0 || result.TimedOut {
+		return nil, fmt.Errorf("sandbox: %s doesn't run in the sandbox: %s", python, strings.TrimSpace(result.Stderr))
+	}
+	return s, nil
+}
+
+// RunPython runs code as a Python script. A snippet that fails, times out or
+// hits a limit is not an error, the Result tells what happened.
+func (s *Sandbox) RunPython(ctx context.Context, code string) (*Result, error) {
+	dir, err := os.MkdirTemp("", "sandbox-*")
+	if err != nil {
+		return nil, fmt.Errorf("sandbox: can't create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	work := filepath.Join(dir, "work")
+	if err := os.Mkdir(work, 0o700); err != nil {
+		return nil, fmt.Errorf("sandbox: can't create work dir: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(work, "main.py"), []byte(code), 0o600); err != nil {
+		return nil, fmt.Errorf("sandbox: can't write script: %w", err)
+	}
+
+	// -I isolates from the user site packages and PYTHON* variables.
+	return s.run(ctx, dir, s.python, "-I", "main.py")
+}
+
+// run runs name in the sandbox. dir holds the "work" directory the command
+// starts in.
+func (s *Sandbox) run(ctx context.Context, dir string, name string, args ...string) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	cmd, err := s.command(ctx, dir, name, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	stdout := &limitedBuffer{limit: s.config.OutputBytes}
+	stderr := &limitedBuffer{limit: s.config.OutputBytes}
+	cmd.Dir = dir
+	cmd.Env = []string{
+		"PATH=/usr/local/bin:/usr/bin:/bin",
+		"HOME=" + workDir,
+		"TMPDIR=" + workDir,
+		"LANG=C.UTF-8",
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err = cmd.Run()
+	result := &Result{
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		ExitCode:  cmd.ProcessState.ExitCode(),
+		Truncated: stdout.truncated || stderr.truncated,
+		Duration:  time.Since(start),
+	}
+
+	switch ctx.Err() {
+	case context.Canceled:
+		return nil, ctx.Err()
+	case context.DeadlineExceeded:
+		result.TimedOut = true
+		return result, nil
+	}
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("sandbox: %w", err)
+	}
+	return result, nil
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the
+// rest. The buffer is not embedded so io.Copy can't bypass Write through
+// ReadFrom.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := b.limit - b.buf.Len(); len(p) > room {
+		p = p[:max(room, 0)]
+		b.truncated = true
+	}
+	b.buf.Write(p)
+	return n, nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
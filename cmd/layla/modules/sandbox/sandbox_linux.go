@@ -0,0 +1,220 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// initName is the argv[0] the sandbox re-executes the current binary with
+// to set up the sandbox from inside its namespaces.
+const initName = "sandbox-init"
+
+// sandboxID is the user and group the snippets run as, mapped to the user
+// running the bot. It isn't root, so the snippets have no capabilities in
+// their namespaces.
+const sandboxID = 65534
+
+// hostDirs are bind mounted read-only into the sandbox root. The interpreter
+// has to be installed under them.
+var hostDirs = []string{"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32", "/etc"}
+
+var devices = []string{"/dev/null", "/dev/zero", "/dev/random", "/dev/urandom"}
+
+func init() {
+	if len(os.Args) == 0 || os.Args[0] != initName {
+		return
+	}
+	if err := sandboxInit(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+		os.Exit(127)
+	}
+}
+
+// supported checks that unprivileged user namespaces are enabled, New also
+// runs the interpreter once to be sure.
+func supported() error {
+	data, err := os.ReadFile("/proc/sys/user/max_user_namespaces")
+	if err == nil && strings.TrimSpace(string(data)) == "0" {
+		return errors.New("sandbox: user namespaces are disabled by user.max_user_namespaces")
+	}
+	data, err = os.ReadFile("/proc/sys/kernel/unprivileged_userns_clone")
+	if err == nil && strings.TrimSpace(string(data)) == "0" {
+		return errors.New("sandbox: unprivileged user namespaces are disabled by kernel.unprivileged_userns_clone")
+	}
+	return nil
+}
+
+// command re-executes the current binary as initName in new user, mount,
+// network, PID and IPC namespaces. The network namespace has no interfaces
+// but a down loopback, and killing the first process on timeout takes all
+// its children down with the PID namespace. sandboxInit builds the
+// filesystem, then a shell wrapper sets the resource limits, as the Go
+// runtime of sandboxInit can't run under them.
+func (s *Sandbox) command(ctx context.Context, dir string, name string, args ...string) (*exec.Cmd, error) {
+	if err := os.Mkdir(filepath.Join(dir, "root"), 0o700); err != nil {
+		return nil, fmt.Errorf("sandbox: can't create root: %w", err)
+	}
+
+	limits := fmt.Sprintf("ulimit -c 0 && ulimit -t %d && ulimit -v %d && ulimit -f %d && exec \"$@\"",
+		max(int(s.config.CPUTime.Seconds()), 1),
+		s.config.MemoryBytes/1024,
+		// The file size is in 512 byte blocks in POSIX shells.
+		max(s.config.FileBytes/512, 1),
+	)
+
+	initArgs := []string{dir, "/bin/sh", "-c", limits, "sandbox", name}
+	cmd := exec.CommandContext(ctx, "/proc/self/exe", append(initArgs, args...)...)
+	cmd.Args[0] = initName
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWNET | syscall.CLONE_NEWPID | syscall.CLONE_NEWIPC,
+		UidMappings: []syscall.SysProcIDMap{
+			{ContainerID: sandboxID, HostID: os.Getuid(), Size: 1},
+		},
+		GidMappings: []syscall.SysProcIDMap{
+			{ContainerID: sandboxID, HostID: os.Getgid(), Size: 1},
+		},
+		GidMappingsEnableSetgroups: false,
+		// sandboxInit needs CAP_SYS_ADMIN for the mounts and drops it
+		// before executing name.
+		AmbientCaps: []uintptr{capSysAdmin},
+		Pdeathsig:   syscall.SIGKILL,
+	}
+	return cmd, nil
+}
+
+const (
+	capSysAdmin = 21
+
+	prSetNoNewPrivs      = 38
+	prCapAmbient         = 47
+	prCapAmbientClearAll = 4
+)
+
+// sandboxInit runs as the first process of the sandbox. It mounts a
+// read-only tmpfs root with the hostDirs, a few devices and the run's work
+// directory as the only writable workDir, switches to it and executes the
+// command. There is no /proc, so a snippet can't look at the bot's process.
+func sandboxInit(args []string) error {
+	if len(args) < 2 {
+		return errors.New("init: missing arguments")
+	}
+	dir, argv := args[0], args[1:]
+
+	// Nothing mounted from here on shows up outside of the sandbox.
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("init: can't make mounts private: %w", err)
+	}
+
+	root := filepath.Join(dir, "root")
+	if err := syscall.Mount("tmpfs", root, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "size=1m,mode=0755"); err != nil {
+		return fmt.Errorf("init: can't mount root: %w", err)
+	}
+
+	for _, path := range hostDirs {
+		info, err := os.Lstat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("init: %w", err)
+		}
+
+		// Merged /usr layouts link /bin and /lib into /usr.
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("init: %w", err)
+			}
+			if err := os.Symlink(target, filepath.Join(root, path)); err != nil {
+				return fmt.Errorf("init: %w", err)
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Join(root, path), 0o755); err != nil {
+			return fmt.Errorf("init: %w", err)
+		}
+		if err := bindMount(path, filepath.Join(root, path), true); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Mkdir(filepath.Join(root, "dev"), 0o755); err != nil {
+		return fmt.Errorf("init: %w", err)
+	}
+	for _, path := range devices {
+		if err := os.WriteFile(filepath.Join(root, path), nil, 0o644); err != nil {
+			return fmt.Errorf("init: %w", err)
+		}
+		if err := bindMount(path, filepath.Join(root, path), false); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Mkdir(filepath.Join(root, workDir), 0o755); err != nil {
+		return fmt.Errorf("init: %w", err)
+	}
+	if err := bindMount(filepath.Join(dir, "work"), filepath.Join(root, workDir), false); err != nil {
+		return err
+	}
+
+	// Swap the root and detach the old one, the host filesystem is out of
+	// reach afterwards.
+	if err := syscall.Chdir(root); err != nil {
+		return fmt.Errorf("init: %w", err)
+	}
+	if err := syscall.PivotRoot(".", "."); err != nil {
+		return fmt.Errorf("init: can't pivot root: %w", err)
+	}
+	if err := syscall.Unmount(".", syscall.MNT_DETACH); err != nil {
+		return fmt.Errorf("init: can't detach the old root: %w", err)
+	}
+	if err := syscall.Mount("", "/", "", syscall.MS_REMOUNT|syscall.MS_BIND|syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("init: can't make root read-only: %w", err)
+	}
+	if err := syscall.Chdir(workDir); err != nil {
+		return fmt.Errorf("init: %w", err)
+	}
+
+	// Without the ambient capability the command starts with none, as
+	// sandboxID isn't root.
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prCapAmbient, prCapAmbientClearAll, 0, 0, 0, 0); errno != 0 {
+		return fmt.Errorf("init: can't drop capabilities: %w", errno)
+	}
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0, 0, 0, 0); errno != 0 {
+		return fmt.Errorf("init: can't set no_new_privs: %w", errno)
+	}
+
+	return syscall.Exec(argv[0], argv, os.Environ())
+}
+
+// bindMount mounts source on target. The nosuid, nodev and noexec flags of
+// the source mount are locked in a user namespace and have to be kept when
+// remounting it read-only, the atime flags are kept by the kernel.
+func bindMount(source, target string, readOnly bool) error {
+	if err := syscall.Mount(source, target, "", syscall.MS_BIND, ""); err != nil {
+		return fmt.Errorf("init: can't mount %s: %w", source, err)
+	}
+	if !readOnly {
+		return nil
+	}
+
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(source, &st); err != nil {
+		return fmt.Errorf("init: %w", err)
+	}
+	flags := uintptr(syscall.MS_REMOUNT | syscall.MS_BIND | syscall.MS_RDONLY)
+	// The statfs flags have the same values as the mount ones.
+	flags |= uintptr(st.Flags) & (syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC)
+	if err := syscall.Mount("", target, "", flags, ""); err != nil {
+		return fmt.Errorf("init: can't make %s read-only: %w", source, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFilesystemIsolation(t *testing.T) {
+	sb, err := New(Config{Python: "/usr/bin/python3"})
+	if err != nil {
+		t.Skipf("sandbox unavailable: %v", err)
+	}
+
+	// A file next to the bot must be out of reach.
+	secret := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secret, []byte("token"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"host file", "import os; print(os.path.exists(" + pyString(secret) + "))", "False"},
+		{"proc", "import os; print(os.path.exists('/proc/1'))", "False"},
+		{"system dirs read-only", "open('/usr/sandbox-test', 'w')", "Read-only file system"},
+		{"root read-only", "open('/sandbox-test', 'w')", "Read-only file system"},
+		{"work dir", "open('/tmp/x', 'w').write('ok'); print(open('/tmp/x').read())", "ok"},
+		{"no network", "import socket; socket.create_connection(('1.1.1.1', 80), timeout=1)", "OSError"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := sb.RunPython(context.Background(), tt.code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out := result.Stdout + result.Stderr; !strings.Contains(out, tt.want) {
+				t.Errorf("output %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func pyString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"context"
+	"os/exec"
+)
+
+func supported() error {
+	return ErrUnsupported
+}
+
+func (s *Sandbox) command(ctx context.Context, dir string, name string, args ...string) (*exec.Cmd, error) {
+	return nil, ErrUnsupported
+}
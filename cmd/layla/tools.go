@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"git.jakub.app/jakub/X/cmd/layla/modules/ai"
+	"git.jakub.app/jakub/X/cmd/layla/modules/sandbox"
+	"git.jakub.app/jakub/X/internal/env"
+	"git.jakub.app/jakub/X/internal/kuchniaviking"
+	"git.jakub.app/jakub/X/internal/llm"
+	"git.jakub.app/jakub/X/internal/vocab"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	MAX_TOOL_ROUNDS = env.GetEnvAsInt("LAYLA_MAX_TOOL_ROUNDS", llm.DefaultMaxToolRounds)
+
+	CODE_INTERPRETER = env.GetEnvAsBool("LAYLA_CODE_INTERPRETER", true)
+	// SANDBOX_PYTHON has to be installed under /usr, the sandbox only sees
+	// the system directories.
+	SANDBOX_PYTHON    = env.GetEnv("LAYLA_SANDBOX_PYTHON", "python3")
+	SANDBOX_TIMEOUT   = env.GetEnvAsDuration("LAYLA_SANDBOX_TIMEOUT", 10*time.Second)
+	SANDBOX_CPU_TIME  = env.GetEnvAsDuration("LAYLA_SANDBOX_CPU_TIME", 5*time.Second)
+	SANDBOX_MEMORY_MB = env.GetEnvAsInt("LAYLA_SANDBOX_MEMORY_MB", 256)
+)
+
+// chatTools returns the tools layla can use while chatting.
+func (s *svc) chatTools(client *llm.Client) *ai.Registry {
+	tools := ai.NewRegistry()
+
+	tools.Register(llm.Tool{
+		Name:        "get_menu",
+		Description: "Get the meals of the catering menu for a day, with nutrition and the allergens found in them.",
+		Parameters: ai.Schema(map[string]any{
+			"date": map[string]any{
+				"type":        "string",
+				"description": `"today", "tomorrow" or a date in the YYYY-MM-DD format, defaults to today`,
+			},
+		}),
+	}, s.getMenuTool)
+
+	tools.Register(llm.Tool{
+		Name:        "translate_word",
+		Description: "Translate an English word or sentence to Polish, returns the translation with its part of speech, a usage example and the pronunciation.",
+		Parameters: ai.Schema(map[string]any{
+			"word": map[string]any{
+				"type":        "string",
+				"description": "The word or sentence to translate",
+			},
+		}, "word"),
+	}, translateTool(client))
+
+	if CODE_INTERPRETER {
+		sb, err := sandbox.New(sandbox.Config{
+			Python:      SANDBOX_PYTHON,
+			Timeout:     SANDBOX_TIMEOUT,
+			CPUTime:     SANDBOX_CPU_TIME,
+			MemoryBytes: int64(SANDBOX_MEMORY_MB) << 20,
+		})
+		if err != nil {
+			log.Warn().Err(err).Msg("can't create the sandbox, code_interpreter is disabled")
+		} else {
+			tools.Register(llm.Tool{
+				Name:        "code_interpreter",
+				Description: "Run a Python 3 script in a sandbox without network access and return its stdout, stderr and exit code. Print the results you need.",
+				Parameters: ai.Schema(map[string]any{
+					"code": map[string]any{
+						"type":        "string",
+						"description": "The Python source to run",
+					},
+				}, "code"),
+			}, codeInterpreterTool(sb))
+		}
+	}
+
+	return tools
+}
+
+type menuMeal struct {
+	Meal      string   `json:"meal"`
+	Name      string   `json:"name"`
+	Calories  float64  `json:"kcal"`
+	Protein   float64  `json:"protein"`
+	Fat       float64  `json:"fat"`
+	Carbs     float64  `json:"carbs"`
+	Allergens []string `json:"allergens,omitempty"`
+}
+
+func (s *svc) getMenuTool(ctx context.Context, args json.RawMessage) (string, error) {
+	var params struct {
+		Date string `json:"date"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return "", err
+	}
+
+	date := today()
+	switch strings.ToLower(strings.TrimSpace(params.Date)) {
+	case "", "today":
+	case "tomorrow":
+		date = date.AddDate(0, 0, 1)
+	default:
+		parsed, err := time.Parse(dateLayout, params.Date)
+		if err != nil {
+			return "", fmt.Errorf("invalid date %q, expected YYYY-MM-DD", params.Date)
+		}
+		date = parsed
+	}
+
+	menus, err := s.menus(date, 1)
+	if err != nil {
+		return "", err
+	}
+	day := menus[0]
+
+	if note, ok := missingMenu(day); !ok {
+		return fmt.Sprintf("%s on %s.", note, date.Format(dateLayout)), nil
+	}
+
+	profile := kuchniaviking.DefaultAllergenProfile()
+	meals := make([]menuMeal, 0, len(day.Meals))
+	for _, meal := range day.Meals {
+		m := menuMeal{
+			Meal:     meal.MealName,
+			Name:     meal.MenuMealName,
+			Calories: meal.Nutrition.Calories,
+			Protein:  meal.Nutrition.Protein,
+			Fat:      meal.Nutrition.Fat,
+			Carbs:    meal.Nutrition.Carbohydrate,
+		}
+		for _, match := range profile.Evaluate(meal) {
+			m.Allergens = append(m.Allergens, fmt.Sprintf("%s (%s)", match.Ingredient, match.Allergen))
+		}
+		meals = append(meals, m)
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"date":  date.Format(dateLayout),
+		"meals": meals,
+	})
+	return string(data), err
+}
+
+func translateTool(client *llm.Client) ai.ToolFunc {
+	return func(ctx context.Context, args json.RawMessage) (string, error) {
+		var params struct {
+			Word string `json:"word"`
+		}
+		if err := json.Unmarshal(args, &params); err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(params.Word) == "" {
+			return "", fmt.Errorf("word is required")
+		}
+
+		resp, err := client.Complete(ctx, llm.CompletionRequest{
+			Messages: []llm.Message{
+				{Role: llm.RoleUser, Content: vocab.Prompt(params.Word)},
+			},
+			MaxTokens:   200,
+			Temperature: 0.3,
+		})
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(resp.Content), nil
+	}
+}
+
+func codeInterpreterTool(sb *sandbox.Sandbox) ai.ToolFunc {
+	return func(ctx context.Context, args json.RawMessage) (string, error) {
+		var params struct {
+			Code string `json:"code"`
+		}
+		if err := json.Unmarshal(args, &params); err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(params.Code) == "" {
+			return "", fmt.Errorf("code is required")
+		}
+
+		result, err := sb.RunPython(ctx, params.Code)
+		if err != nil {
+			return "", err
+		}
+
+		data, err := json.Marshal(result)
+		return string(data), err
+	}
+}
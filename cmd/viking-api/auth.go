@@ -0,0 +1,202 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"git.jakub.app/jakub/X/internal/env"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// VIKING_API_TOKENS is a comma separated list of name:scope:token entries,
+	// where scope is either "read" or "write".
+	VIKING_API_TOKENS = env.GetEnvAsSlice("VIKING_API_TOKENS", nil, ",")
+	// VIKING_API_BASIC_AUTH is a comma separated list of user:password entries
+	// accepted with read scope on the HTML view.
+	VIKING_API_BASIC_AUTH = env.GetEnvAsSlice("VIKING_API_BASIC_AUTH", nil, ",")
+)
+
+type scope int
+
+const (
+	scopeRead scope = iota + 1
+	scopeWrite
+)
+
+func (s scope) String() string {
+	switch s {
+	case scopeRead:
+		return "read"
+	case scopeWrite:
+		return "write"
+	}
+	return "unknown"
+}
+
+func parseScope(s string) (scope, error) {
+	switch strings.ToLower(s) {
+	case "read":
+		return scopeRead, nil
+	case "write":
+		return scopeWrite, nil
+	}
+	return 0, fmt.Errorf("unknown scope %q", s)
+}
+
+type apiToken struct {
+	name  string
+	scope scope
+	token string
+}
+
+type basicCredential struct {
+	user     string
+	password string
+}
+
+type principal struct {
+	name   string
+	scope  scope
+	method string
+}
+
+type authenticator struct {
+	tokens []apiToken
+	basic  []basicCredential
+}
+
+func newAuthenticator(tokens, basic []string) (*authenticator, error) {
+	a := &authenticator{}
+
+	for _, entry := range tokens {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid api token entry, expected name:scope:token")
+		}
+		sc, err := parseScope(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid api token %q: %w", parts[0], err)
+		}
+		a.tokens = append(a.tokens, apiToken{name: parts[0], scope: sc, token: parts[2]})
+	}
+
+	for _, entry := range basic {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		user, password, ok := strings.Cut(entry, ":")
+		if !ok || user == "" || password == "" {
+			return nil, fmt.Errorf("invalid basic auth entry, expected user:password")
+		}
+		a.basic = append(a.basic, basicCredential{user: user, password: password})
+	}
+
+	if len(a.tokens) == 0 && len(a.basic) == 0 {
+		log.Warn().Msg("no api tokens configured, every authenticated endpoint will answer 401")
+	}
+
+	return a, nil
+}
+
+func (a *authenticator) authenticate(r *http.Request, allowBasic bool) (*principal, bool) {
+	if token := bearerToken(r); token != "" {
+		for _, t := range a.tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(t.token)) == 1 {
+				return &principal{name: t.name, scope: t.scope, method: "token"}, true
+			}
+		}
+		return nil, false
+	}
+
+	if user, password, ok := r.BasicAuth(); ok && allowBasic {
+		for _, c := range a.basic {
+			userMatch := subtle.ConstantTimeCompare([]byte(user), []byte(c.user))
+			passwordMatch := subtle.ConstantTimeCompare([]byte(password), []byte(c.password))
+			if userMatch&passwordMatch == 1 {
+				return &principal{name: c.user, scope: scopeRead, method: "basic"}, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func bearerToken(r *http.Request) string {
+	if token := r.Header.Get("X-API-Token"); token != "" {
+		return token
+	}
+	auth := r.Header.Get("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+// require rejects requests without a principal holding at least the required
+// scope. allowBasic additionally accepts HTTP basic auth credentials, which is
+// meant for views opened in a browser.
+func (a *authenticator) require(required scope, allowBasic bool, next http.Handler) http.Handler {
+	return a.requireWith(required, allowBasic, func(w http.ResponseWriter, r *http.Request, code int, message string) {
+		http.Error(w, message, code)
+	}, next)
+}
+
+// requireV1 is require for /api/v1 routes, rejections use the v1 envelope.
+func (a *authenticator) requireV1(required scope, next http.Handler) http.Handler {
+	return a.requireWith(required, false, func(w http.ResponseWriter, r *http.Request, code int, message string) {
+		respondV1Error(w, r, code, strings.ToLower(strings.ReplaceAll(message, " ", "_")), message, nil)
+	}, next)
+}
+
+func (a *authenticator) requireWith(required scope, allowBasic bool, reject func(w http.ResponseWriter, r *http.Request, code int, message string), next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := a.authenticate(r, allowBasic)
+		if !ok {
+			log.Warn().
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Str("remoteAddr", r.RemoteAddr).
+				Msg("unauthenticated request")
+			if allowBasic {
+				w.Header().Set("WWW-Authenticate", `Basic realm="viking-api", charset="UTF-8"`)
+			}
+			reject(w, r, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		if p.scope < required {
+			log.Warn().
+				Str("principal", p.name).
+				Str("scope", p.scope.String()).
+				Str("requiredScope", required.String()).
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Msg("forbidden request")
+			reject(w, r, http.StatusForbidden, "Forbidden")
+			return
+		}
+
+		rec := newStatusRecorder(w)
+		next.ServeHTTP(rec, r)
+
+		log.Info().
+			Str("requestId", requestIDFromContext(r.Context())).
+			Str("principal", p.name).
+			Str("auth", p.method).
+			Str("scope", p.scope.String()).
+			Str("method", r.Method).
+			Str("path", r.URL.Path).
+			Str("query", r.URL.RawQuery).
+			Str("remoteAddr", r.RemoteAddr).
+			Int("status", rec.status).
+			Msg("audit")
+	})
+}
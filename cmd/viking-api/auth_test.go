@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewAuthenticator(t *testing.T) {
+	tests := []struct {
+		name       string
+		tokens     []string
+		basic      []string
+		wantErr    bool
+		wantTokens []apiToken
+	}{
+		{
+			name:       "valid",
+			tokens:     []string{"ci:read:abc", " admin:WRITE:def "},
+			basic:      []string{"user:pass"},
+			wantTokens: []apiToken{{"ci", scopeRead, "abc"}, {"admin", scopeWrite, "def"}},
+		},
+		{
+			name:       "token with colons",
+			tokens:     []string{"ci:read:a:b:c"},
+			wantTokens: []apiToken{{"ci", scopeRead, "a:b:c"}},
+		},
+		{name: "blank entries", tokens: []string{"", "  "}, basic: []string{""}},
+		{name: "missing token", tokens: []string{"ci:read"}, wantErr: true},
+		{name: "empty token", tokens: []string{"ci:read:"}, wantErr: true},
+		{name: "empty name", tokens: []string{":read:abc"}, wantErr: true},
+		{name: "token only", tokens: []string{"abc"}, wantErr: true},
+		{name: "unknown scope", tokens: []string{"ci:admin:abc"}, wantErr: true},
+		{name: "empty scope", tokens: []string{"ci::abc"}, wantErr: true},
+		{name: "basic without password", basic: []string{"user"}, wantErr: true},
+		{name: "basic with empty password", basic: []string{"user:"}, wantErr: true},
+		{name: "basic with empty user", basic: []string{":pass"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := newAuthenticator(tt.tokens, tt.basic)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newAuthenticator() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(a.tokens) != len(tt.wantTokens) {
+				t.Fatalf("tokens = %+v, want %+v", a.tokens, tt.wantTokens)
+			}
+			for i, want := range tt.wantTokens {
+				if a.tokens[i] != want {
+					t.Errorf("token %d = %+v, want %+v", i, a.tokens[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRequire(t *testing.T) {
+	a, err := newAuthenticator([]string{"reader:read:r-token", "writer:write:w-token"}, []string{"user:pass"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		required      scope
+		allowBasic    bool
+		header        http.Header
+		basic         []string
+		wantCode      int
+		wantChallenge bool
+	}{
+		{name: "no credentials", required: scopeRead, wantCode: http.StatusUnauthorized},
+		{name: "no credentials with basic", required: scopeRead, allowBasic: true, wantCode: http.StatusUnauthorized, wantChallenge: true},
+		{name: "unknown token", required: scopeRead, header: bearer("nope"), wantCode: http.StatusUnauthorized},
+		{name: "read token", required: scopeRead, header: bearer("r-token"), wantCode: http.StatusOK},
+		{name: "lowercase bearer", required: scopeRead, header: http.Header{"Authorization": {"bearer r-token"}}, wantCode: http.StatusOK},
+		{name: "X-API-Token", required: scopeRead, header: http.Header{"X-Api-Token": {"r-token"}}, wantCode: http.StatusOK},
+		{name: "read token on write", required: scopeWrite, header: bearer("r-token"), wantCode: http.StatusForbidden},
+		{name: "write token on read", required: scopeRead, header: bearer("w-token"), wantCode: http.StatusOK},
+		{name: "write token", required: scopeWrite, header: bearer("w-token"), wantCode: http.StatusOK},
+		{name: "basic", required: scopeRead, allowBasic: true, basic: []string{"user", "pass"}, wantCode: http.StatusOK},
+		{name: "basic wrong password", required: scopeRead, allowBasic: true, basic: []string{"user", "nope"}, wantCode: http.StatusUnauthorized, wantChallenge: true},
+		{name: "basic not allowed", required: scopeRead, basic: []string{"user", "pass"}, wantCode: http.StatusUnauthorized},
+		{name: "basic on write", required: scopeWrite, allowBasic: true, basic: []string{"user", "pass"}, wantCode: http.StatusForbidden},
+		// A wrong token isn't retried as basic auth.
+		{name: "unknown token with basic", required: scopeRead, allowBasic: true, header: http.Header{"X-Api-Token": {"nope"}}, basic: []string{"user", "pass"}, wantCode: http.StatusUnauthorized, wantChallenge: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called bool
+			h := a.require(tt.required, tt.allowBasic, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/deliveries", nil)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			if tt.basic != nil {
+				req.SetBasicAuth(tt.basic[0], tt.basic[1])
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if called != (tt.wantCode == http.StatusOK) {
+				t.Errorf("handler called = %v, want %v", called, !called)
+			}
+			if challenge := rec.Header().Get("WWW-Authenticate") != ""; challenge != tt.wantChallenge {
+				t.Errorf("WWW-Authenticate sent = %v, want %v", challenge, tt.wantChallenge)
+			}
+		})
+	}
+}
+
+func TestRequireV1(t *testing.T) {
+	a, err := newAuthenticator([]string{"reader:read:r-token"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := a.requireV1(scopeWrite, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name     string
+		header   http.Header
+		wantCode int
+		wantErr  string
+	}{
+		{"no credentials", nil, http.StatusUnauthorized, "unauthorized"},
+		{"wrong scope", bearer("r-token"), http.StatusForbidden, "forbidden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/cache/purge", nil)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			var envelope Envelope
+			if err := json.Unmarshal(rec.Body.Bytes(), &envelope); err != nil {
+				t.Fatalf("body %q: %v", rec.Body.String(), err)
+			}
+			if envelope.Error == nil || envelope.Error.Code != tt.wantErr {
+				t.Errorf("error = %+v, want code %q", envelope.Error, tt.wantErr)
+			}
+		})
+	}
+}
+
+func bearer(token string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"git.jakub.app/jakub/X/internal/cache"
+	"git.jakub.app/jakub/X/internal/env"
+	valkey "github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	VALKEY_URL = env.GetEnv("VALKEY_URL", "")
+)
+
+// newCacheStore connects to Valkey when VALKEY_URL is set and reachable,
+// otherwise it falls back to an in-process cache.
+func newCacheStore() cache.Store {
+	if VALKEY_URL == "" {
+		log.Info().Msg("VALKEY_URL not set, using in-memory cache")
+		return cache.NewMemory()
+	}
+
+	opts, err := valkey.ParseURL(VALKEY_URL)
+	if err != nil {
+		log.Error().Err(err).Msg("can't parse VALKEY_URL, using in-memory cache")
+		return cache.NewMemory()
+	}
+
+	rdb := valkey.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		log.Error().Err(err).Msg("can't reach valkey, using in-memory cache")
+		rdb.Close()
+		return cache.NewMemory()
+	}
+
+	log.Info().Str("addr", opts.Addr).Msg("using valkey cache")
+	return cache.NewValkey(rdb, "viking-api:")
+}
+
+// writeWithETag writes body tagged with a content hash and answers 304 when
+// the client already has the same representation.
+func writeWithETag(w http.ResponseWriter, r *http.Request, contentType string, code int, body []byte) {
+	writeWithTag(w, r, contentType, code, body, computeETag(body))
+}
+
+// computeETag returns a weak ETag, gzipMiddleware sends the same tag for the
+// compressed and the identity encoding, which aren't byte-for-byte equal.
+func computeETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// writeWithTag is writeWithETag for bodies containing per-request data, such
+// as a request ID, where the ETag is computed from the stable part only.
+func writeWithTag(w http.ResponseWriter, r *http.Request, contentType string, code int, body []byte, etag string) {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", contentType)
+
+	cacheable := r.Method == http.MethodGet || r.Method == http.MethodHead
+	if cacheable && code == http.StatusOK && etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.WriteHeader(code)
+	w.Write(body)
+}
+
+// etagMatches uses the weak comparison If-None-Match calls for, tags match
+// regardless of their W/ prefix.
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) PurgeCacheHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.cache.Purge(r.Context()); err != nil {
+		log.Error().Err(err).Msg("Failed to purge cache")
+		s.respondWithError(w, r, http.StatusInternalServerError, "Failed to purge cache")
+		return
+	}
+
+	log.Info().Msg("Cache purged")
+	s.respondWithJSON(w, r, http.StatusOK, map[string]bool{"purged": true})
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"git.jakub.app/jakub/X/internal/cache"
+)
+
+func TestWriteWithETag(t *testing.T) {
+	body := []byte(`{"deliveries":[]}`)
+	etag := computeETag(body)
+	strong := strings.TrimPrefix(etag, "W/")
+
+	tests := []struct {
+		name        string
+		method      string
+		code        int
+		ifNoneMatch string
+		wantCode    int
+	}{
+		{"no If-None-Match", http.MethodGet, http.StatusOK, "", http.StatusOK},
+		{"weak match", http.MethodGet, http.StatusOK, etag, http.StatusNotModified},
+		{"strong match", http.MethodGet, http.StatusOK, strong, http.StatusNotModified},
+		{"match in list", http.MethodGet, http.StatusOK, `W/"other", ` + etag, http.StatusNotModified},
+		{"wildcard", http.MethodGet, http.StatusOK, "*", http.StatusNotModified},
+		{"HEAD match", http.MethodHead, http.StatusOK, etag, http.StatusNotModified},
+		{"mismatch", http.MethodGet, http.StatusOK, `W/"other"`, http.StatusOK},
+		{"POST match", http.MethodPost, http.StatusOK, etag, http.StatusOK},
+		{"error match", http.MethodGet, http.StatusNotFound, etag, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/deliveries", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+			writeWithETag(rec, req, "application/json", tt.code, body)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if got := rec.Header().Get("ETag"); got != etag {
+				t.Errorf("ETag = %q, want %q", got, etag)
+			}
+			wantBody := string(body)
+			if tt.wantCode == http.StatusNotModified {
+				wantBody = ""
+			}
+			if rec.Body.String() != wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), wantBody)
+			}
+		})
+	}
+}
+
+func TestETagWithGzip(t *testing.T) {
+	body := []byte(strings.Repeat(`{"meal":"soup"}`, 100))
+	h := gzipMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeWithETag(w, r, "application/json", http.StatusOK, body)
+	}))
+
+	get := func(acceptEncoding, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/deliveries", nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	identity := get("identity", "")
+	gzipped := get("gzip", "")
+	if gzipped.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("response is not gzip encoded")
+	}
+	zr, err := gzip.NewReader(gzipped.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(zr); string(data) != string(body) {
+		t.Error("gzip body differs from the identity body")
+	}
+
+	etag := identity.Header().Get("ETag")
+	if !strings.HasPrefix(etag, "W/") {
+		t.Errorf("ETag = %q, want a weak tag", etag)
+	}
+	if got := gzipped.Header().Get("ETag"); got != etag {
+		t.Errorf("gzip ETag = %q, identity ETag = %q, want the same weak tag", got, etag)
+	}
+
+	// Either encoding revalidates a tag received with the other one.
+	rec := get("gzip", etag)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotModified)
+	}
+	if rec.Header().Get("Content-Encoding") != "" || rec.Body.Len() != 0 {
+		t.Errorf("304 has Content-Encoding %q and %d body bytes, want neither", rec.Header().Get("Content-Encoding"), rec.Body.Len())
+	}
+}
+
+func TestNewCacheStoreFallback(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"not set", ""},
+		{"invalid URL", "http://valkey:6379"},
+		// Nothing listens on port 1.
+		{"unreachable", "redis://127.0.0.1:1/0"},
+	}
+	defer func(url string) { VALKEY_URL = url }(VALKEY_URL)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			VALKEY_URL = tt.url
+			store := newCacheStore()
+			if _, ok := store.(*cache.Memory); !ok {
+				t.Errorf("newCacheStore() = %T, want *cache.Memory", store)
+			}
+		})
+	}
+}
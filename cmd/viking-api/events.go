@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"git.jakub.app/jakub/X/internal/env"
+	"git.jakub.app/jakub/X/internal/kuchniaviking"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	EVENTS_POLL_INTERVAL = env.GetEnvAsDuration("VIKING_API_EVENTS_POLL_INTERVAL", 5*time.Minute)
+	EVENTS_DAYS          = env.GetEnvAsInt("VIKING_API_EVENTS_DAYS", 14)
+	EVENTS_HEARTBEAT     = env.GetEnvAsDuration("VIKING_API_EVENTS_HEARTBEAT", 30*time.Second)
+)
+
+const eventHistorySize = 100
+
+type event struct {
+	ID     uint64
+	Change kuchniaviking.Change
+}
+
+// eventBroker fans out change events to SSE subscribers and keeps a short
+// history so reconnecting clients can resume from Last-Event-ID.
+type eventBroker struct {
+	mu          sync.Mutex
+	nextID      uint64
+	history     []event
+	subscribers map[chan event]struct{}
+	closed      chan struct{}
+	closeOnce   sync.Once
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		nextID:      1,
+		subscribers: make(map[chan event]struct{}),
+		closed:      make(chan struct{}),
+	}
+}
+
+func (b *eventBroker) publish(change kuchniaviking.Change) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e := event{ID: b.nextID, Change: change}
+	b.nextID++
+
+	b.history = append(b.history, e)
+	if len(b.history) > eventHistorySize {
+		b.history = b.history[len(b.history)-eventHistorySize:]
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+			log.Warn().Uint64("eventId", e.ID).Msg("Dropping event for slow subscriber")
+		}
+	}
+}
+
+// subscribe registers a subscriber and returns the events it missed since
+// lastID.
+func (b *eventBroker) subscribe(lastID uint64) (chan event, []event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan event, 16)
+	b.subscribers[ch] = struct{}{}
+
+	var missed []event
+	if lastID > 0 {
+		for _, e := range b.history {
+			if e.ID > lastID {
+				missed = append(missed, e)
+			}
+		}
+	}
+	return ch, missed
+}
+
+func (b *eventBroker) unsubscribe(ch chan event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscribers, ch)
+}
+
+// close ends every open stream, used when the server shuts down.
+func (b *eventBroker) close() {
+	b.closeOnce.Do(func() {
+		close(b.closed)
+	})
+}
+
+// pollChanges snapshots the upstream every interval and publishes the
+// differences. The first snapshot only sets the baseline.
+func (s *Server) pollChanges(ctx context.Context) {
+	var (
+		kv       kuchniaviking.KuchniaVikinga
+		previous *kuchniaviking.Snapshot
+	)
+
+	ticker := time.NewTicker(EVENTS_POLL_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		if kv == nil {
+			var err error
+			kv, err = kuchniaviking.New()
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to initialize KuchniaVikinga for change polling")
+			}
+		}
+
+		if kv != nil {
+			now := time.Now()
+			today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+			snapshot, err := kuchniaviking.TakeSnapshot(kv, kuchniaviking.DeliveryQuery{
+				From: today,
+				To:   today.AddDate(0, 0, EVENTS_DAYS),
+			})
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to take delivery snapshot")
+			} else {
+				if previous != nil {
+					for _, change := range kuchniaviking.DiffSnapshots(*previous, snapshot) {
+						log.Info().Str("type", string(change.Type)).Int("deliveryId", change.DeliveryID).Msg("Delivery change detected")
+						s.events.publish(change)
+					}
+				}
+				previous = &snapshot
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) EventsHandler(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	// Streams outlive the server write timeout.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Warn().Err(err).Msg("Failed to clear write deadline for event stream")
+	}
+
+	var lastID uint64
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		lastID, _ = strconv.ParseUint(v, 10, 64)
+	}
+
+	ch, missed := s.events.subscribe(lastID)
+	defer s.events.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: 10000\n\n")
+	for _, e := range missed {
+		if err := writeEvent(w, e); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		log.Error().Err(err).Msg("Event stream does not support flushing")
+		return
+	}
+
+	heartbeat := time.NewTicker(EVENTS_HEARTBEAT)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.events.closed:
+			return
+		case e := <-ch:
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprintf(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e event) error {
+	data, err := json.Marshal(e.Change)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Change.Type, data)
+	return err
+}
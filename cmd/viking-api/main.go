@@ -1,24 +1,51 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"html/template"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"git.jakub.app/jakub/X/cmd/layla/modules/discord"
+	"git.jakub.app/jakub/X/internal/cache"
 	"git.jakub.app/jakub/X/internal/env"
 	"git.jakub.app/jakub/X/internal/kuchniaviking"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog/log"
 )
 
 var (
 	DISCORD_WEBHOOK_URL = env.GetEnv("DISCORD_WEBHOOK", "")
+
+	READ_TIMEOUT        = env.GetEnvAsDuration("VIKING_API_READ_TIMEOUT", 15*time.Second)
+	READ_HEADER_TIMEOUT = env.GetEnvAsDuration("VIKING_API_READ_HEADER_TIMEOUT", 5*time.Second)
+	WRITE_TIMEOUT       = env.GetEnvAsDuration("VIKING_API_WRITE_TIMEOUT", 60*time.Second)
+	IDLE_TIMEOUT        = env.GetEnvAsDuration("VIKING_API_IDLE_TIMEOUT", 120*time.Second)
+	SHUTDOWN_TIMEOUT    = env.GetEnvAsDuration("VIKING_API_SHUTDOWN_TIMEOUT", 30*time.Second)
+	CORS_ORIGINS        = env.GetEnvAsSlice("VIKING_API_CORS_ORIGINS", nil, ",")
 )
 
 type Server struct {
-	router        *mux.Router
-	discordModule *discord.Discord
+	router          *mux.Router
+	discordModule   *discord.Discord
+	allergenProfile kuchniaviking.AllergenProfile
+	cache           cache.Store
+	cacheTTL        kuchniaviking.CacheTTL
+	auth            *authenticator
+	events          *eventBroker
+
+	kvMu sync.Mutex
+	kv   kuchniaviking.KuchniaVikinga
 }
 
 type APIResponse struct {
@@ -31,7 +58,7 @@ type DeliveryResponse struct {
 	Date         string                           `json:"date"`
 	DeliveryID   int                              `json:"deliveryId"`
 	Meals        []kuchniaviking.DeliveryMenuItem `json:"meals"`
-	AllergyMeals []kuchniaviking.DeliveryMenuItem `json:"allergyMeals"`
+	AllergyMeals []kuchniaviking.AllergyMeal      `json:"allergyMeals"`
 }
 
 type HTMLDeliveryData struct {
@@ -41,11 +68,12 @@ type HTMLDeliveryData struct {
 }
 
 type MealData struct {
-	MealName     string
-	MenuMealName string
-	Nutrition    kuchniaviking.Nutrition
-	Ingredients  []IngredientData
-	Allergens    []string
+	MealName        string
+	MenuMealName    string
+	Nutrition       kuchniaviking.Nutrition
+	Ingredients     []IngredientData
+	Allergens       []string
+	AllergenMatches []kuchniaviking.AllergenMatch
 }
 
 type IngredientData struct {
@@ -54,9 +82,22 @@ type IngredientData struct {
 }
 
 func NewServer() (*Server, error) {
+	if err := kuchniaviking.RegisterMetrics(prometheus.DefaultRegisterer); err != nil {
+		return nil, err
+	}
+
+	auth, err := newAuthenticator(VIKING_API_TOKENS, VIKING_API_BASIC_AUTH)
+	if err != nil {
+		return nil, err
+	}
 
 	server := &Server{
-		router:        mux.NewRouter(),
+		router:          mux.NewRouter(),
+		allergenProfile: kuchniaviking.DefaultAllergenProfile(),
+		cache:           newCacheStore(),
+		cacheTTL:        kuchniaviking.DefaultCacheTTL(),
+		auth:            auth,
+		events:          newEventBroker(),
 	}
 
 	server.setupRoutes()
@@ -64,96 +105,211 @@ func NewServer() (*Server, error) {
 }
 
 func (s *Server) setupRoutes() {
-	s.router.HandleFunc("/api/deliveries", s.GetDeliveriesHandler).Methods("GET")
-	s.router.HandleFunc("/api/deliveries/html", s.GetMenuHTMLHandler).Methods("GET")
+	s.router.Use(metricsMiddleware)
+	s.router.Handle("/metrics", promhttp.Handler()).Methods("GET")
+	s.router.HandleFunc("/openapi.json", s.GetOpenAPIHandler).Methods("GET")
+	s.router.Handle("/api/deliveries", s.auth.require(scopeRead, false, http.HandlerFunc(s.GetDeliveriesHandler))).Methods("GET")
+	s.router.Handle("/api/deliveries/html", s.auth.require(scopeRead, true, http.HandlerFunc(s.GetMenuHTMLHandler))).Methods("GET")
+	s.router.Handle("/api/cache/purge", s.auth.require(scopeWrite, false, http.HandlerFunc(s.PurgeCacheHandler))).Methods("POST")
+	s.router.Handle("/api/events", s.auth.require(scopeRead, true, http.HandlerFunc(s.EventsHandler))).Methods("GET")
+
+	v1 := s.router.PathPrefix("/api/v1").Subrouter()
+	v1.Handle("/deliveries", s.auth.requireV1(scopeRead, http.HandlerFunc(s.GetDeliveriesV1Handler))).Methods("GET")
+	v1.Handle("/cache/purge", s.auth.requireV1(scopeWrite, http.HandlerFunc(s.PurgeCacheV1Handler))).Methods("POST")
 }
 
-func (s *Server) GetDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
-	kvService, err := kuchniaviking.New()
+// handler wraps the router with the middlewares shared by every route.
+func (s *Server) handler() http.Handler {
+	return chain(s.router,
+		requestIDMiddleware,
+		accessLogMiddleware,
+		recoverMiddleware,
+		corsMiddleware(CORS_ORIGINS),
+		gzipMiddleware,
+	)
+}
+
+// kuchniaViking returns the shared, cached upstream client, logging in on
+// first use. A failed login is retried on the next call.
+func (s *Server) kuchniaViking() (kuchniaviking.KuchniaVikinga, error) {
+	s.kvMu.Lock()
+	defer s.kvMu.Unlock()
+
+	if s.kv != nil {
+		return s.kv, nil
+	}
+
+	kv, err := kuchniaviking.New()
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "failed to initialize KuchniaVikinga")
-		return
+		return nil, err
+	}
+
+	s.kv = kuchniaviking.NewCached(kv, s.cache, s.cacheTTL)
+	return s.kv, nil
+}
+
+type deliveryMenu struct {
+	Delivery kuchniaviking.Delivery
+	Meals    []kuchniaviking.DeliveryMenuItem
+}
+
+type apiError struct {
+	code    int
+	errCode string
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+// deliveriesPage is one page of deliveries matching a query. Deliveries whose
+// menu could not be fetched are left out and reported in Warnings.
+type deliveriesPage struct {
+	Deliveries []deliveryMenu
+	Total      int
+	Failed     int
+	Warnings   []Warning
+}
+
+func (s *Server) loadDeliveries(query deliveriesQuery) (*deliveriesPage, error) {
+	kvService, err := s.kuchniaViking()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to initialize KuchniaVikinga")
+		return nil, &apiError{http.StatusInternalServerError, "upstream_error", "failed to initialize KuchniaVikinga"}
 	}
 	ids, err := kvService.GetActiveIds()
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to get active orders")
-		return
+		return nil, &apiError{http.StatusInternalServerError, "upstream_error", "Failed to get active orders"}
 	}
 
 	if len(ids) == 0 {
-		s.respondWithError(w, http.StatusNotFound, "No active orders found")
-		return
+		return nil, &apiError{http.StatusNotFound, "no_active_orders", "No active orders found"}
 	}
 
 	orderDataResp, err := kvService.GetOrderData(ids[0])
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to get order data")
-		return
+		return nil, &apiError{http.StatusInternalServerError, "upstream_error", "Failed to get order data"}
 	}
 
-	nearestDeliveries, err := kvService.GetNearestDeliveries(orderDataResp.Deliveries, 7)
+	deliveryQuery := query.DeliveryQuery
+	deliveryQuery.Limit = 0
+	deliveries, err := kvService.GetDeliveries(orderDataResp.Deliveries, deliveryQuery)
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to get nearest deliveries")
-		return
+		return nil, &apiError{http.StatusInternalServerError, "upstream_error", "Failed to get deliveries"}
+	}
+
+	return pageDeliveries(deliveries, query, func(deliveryID int) ([]kuchniaviking.DeliveryMenuItem, error) {
+		deliveryInfo, err := kvService.GetDeliveryInfo(deliveryID)
+		if err != nil {
+			return nil, err
+		}
+		return deliveryInfo.DeliveryMenuMeal, nil
+	}), nil
+}
+
+// pageDeliveries gets the menus of the page of deliveries selected by the
+// query's offset and limit. With a meal filter, deliveries without a
+// matching meal are dropped before the page is cut, which needs the menu of
+// every delivery.
+func pageDeliveries(deliveries []kuchniaviking.Delivery, query deliveriesQuery, menu func(deliveryID int) ([]kuchniaviking.DeliveryMenuItem, error)) *deliveriesPage {
+	page := &deliveriesPage{}
+	if len(query.Meals) == 0 {
+		page.Total = len(deliveries)
+		deliveries = deliveries[min(query.Offset, len(deliveries)):]
+		deliveries = deliveries[:min(query.Limit, len(deliveries))]
 	}
 
-	var response []DeliveryResponse
-	for _, delivery := range nearestDeliveries {
-		deliveryInfo, err := kvService.GetDeliveryInfo(delivery.DeliveryID)
+	for _, delivery := range deliveries {
+		meals, err := menu(delivery.DeliveryID)
 		if err != nil {
 			log.Error().Err(err).Int("deliveryId", delivery.DeliveryID).Msg("Failed to get delivery info")
+			page.Failed++
+			page.Warnings = append(page.Warnings, Warning{
+				Code:       "delivery_unavailable",
+				Message:    fmt.Sprintf("Failed to get menu for delivery on %s", delivery.Date),
+				DeliveryID: delivery.DeliveryID,
+			})
 			continue
 		}
 
-		response = append(response, DeliveryResponse{
-			Date:         delivery.Date,
-			DeliveryID:   delivery.DeliveryID,
-			Meals:        deliveryInfo.DeliveryMenuMeal,
+		meals = query.filterMeals(meals)
+		if len(query.Meals) > 0 && len(meals) == 0 {
+			continue
+		}
+		page.Deliveries = append(page.Deliveries, deliveryMenu{
+			Delivery: delivery,
+			Meals:    meals,
 		})
 	}
 
-	s.respondWithJSON(w, http.StatusOK, response)
+	if len(query.Meals) > 0 {
+		page.Total = len(page.Deliveries)
+		page.Deliveries = page.Deliveries[min(query.Offset, len(page.Deliveries)):]
+		page.Deliveries = page.Deliveries[:min(query.Limit, len(page.Deliveries))]
+	}
+	return page
 }
 
-func (s *Server) GetMenuHTMLHandler(w http.ResponseWriter, r *http.Request) {
-	kvService, err := kuchniaviking.New()
+func (s *Server) GetDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := parseDeliveriesQuery(r)
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "failed to initialize KuchniaVikinga")
+		s.respondWithError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
-	ids, err := kvService.GetActiveIds()
+
+	page, err := s.loadDeliveries(query)
 	if err != nil {
-		http.Error(w, "Failed to get active orders", http.StatusInternalServerError)
+		var apiErr *apiError
+		if errors.As(err, &apiErr) {
+			s.respondWithError(w, r, apiErr.code, apiErr.message)
+			return
+		}
+		s.respondWithError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	if len(ids) == 0 {
-		http.Error(w, "No active orders found", http.StatusNotFound)
-		return
+	response := []DeliveryResponse{}
+	for _, delivery := range page.Deliveries {
+		if len(delivery.Meals) == 0 {
+			continue
+		}
+		response = append(response, DeliveryResponse{
+			Date:         delivery.Delivery.Date,
+			DeliveryID:   delivery.Delivery.DeliveryID,
+			Meals:        delivery.Meals,
+			AllergyMeals: s.allergenProfile.FilterMeals(delivery.Meals),
+		})
 	}
 
-	orderDataResp, err := kvService.GetOrderData(ids[0])
+	s.respondWithJSON(w, r, http.StatusOK, response)
+}
+
+func (s *Server) GetMenuHTMLHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := parseDeliveriesQuery(r)
 	if err != nil {
-		http.Error(w, "Failed to get order data", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	nearestDeliveries, err := kvService.GetNearestDeliveries(orderDataResp.Deliveries, 7)
+	page, err := s.loadDeliveries(query)
 	if err != nil {
-		http.Error(w, "Failed to get nearest deliveries", http.StatusInternalServerError)
+		var apiErr *apiError
+		if errors.As(err, &apiErr) {
+			http.Error(w, apiErr.message, apiErr.code)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	var deliveriesData []HTMLDeliveryData
-	for _, delivery := range nearestDeliveries {
-		deliveryInfo, err := kvService.GetDeliveryInfo(delivery.DeliveryID)
-		if err != nil {
-			log.Error().Err(err).Int("deliveryId", delivery.DeliveryID).Msg("Failed to get delivery info")
+	for _, delivery := range page.Deliveries {
+		if len(delivery.Meals) == 0 {
 			continue
 		}
-
-		meals := make([]MealData, len(deliveryInfo.DeliveryMenuMeal))
-		for i, meal := range deliveryInfo.DeliveryMenuMeal {
+		meals := make([]MealData, len(delivery.Meals))
+		for i, meal := range delivery.Meals {
 			var majorIngredients []IngredientData
 			for _, ing := range meal.Ingredients {
 				if ing.Major {
@@ -165,17 +321,18 @@ func (s *Server) GetMenuHTMLHandler(w http.ResponseWriter, r *http.Request) {
 			}
 
 			meals[i] = MealData{
-				MealName:     meal.MealName,
-				MenuMealName: meal.MenuMealName,
-				Nutrition:    meal.Nutrition,
-				Ingredients:  majorIngredients,
-				Allergens:    meal.Allergens,
+				MealName:        meal.MealName,
+				MenuMealName:    meal.MenuMealName,
+				Nutrition:       meal.Nutrition,
+				Ingredients:     majorIngredients,
+				Allergens:       meal.Allergens,
+				AllergenMatches: s.allergenProfile.Evaluate(meal),
 			}
 		}
 
 		deliveriesData = append(deliveriesData, HTMLDeliveryData{
-			Date:       delivery.Date,
-			DeliveryID: delivery.DeliveryID,
+			Date:       delivery.Delivery.Date,
+			DeliveryID: delivery.Delivery.DeliveryID,
 			Meals:      meals,
 		})
 	}
@@ -232,6 +389,14 @@ func (s *Server) GetMenuHTMLHandler(w http.ResponseWriter, r *http.Request) {
             color: #dc3545;
             font-size: 0.9em;
         }
+        tr.allergy-meal td:not(.date-cell) {
+            background-color: #f8d7da;
+        }
+        .allergen-matches {
+            display: block;
+            margin-top: 4px;
+            font-weight: bold;
+        }
     </style>
 </head>
 <body>
@@ -251,7 +416,7 @@ func (s *Server) GetMenuHTMLHandler(w http.ResponseWriter, r *http.Request) {
                 {{$date := .Date}}
                 {{$mealCount := len .Meals}}
                 {{range $i, $meal := .Meals}}
-                    <tr>
+                    <tr{{if $meal.AllergenMatches}} class="allergy-meal"{{end}}>
                         {{if eq $i 0}}
                             <td rowspan="{{$mealCount}}" class="date-cell">{{$date}}</td>
                         {{end}}
@@ -272,6 +437,11 @@ func (s *Server) GetMenuHTMLHandler(w http.ResponseWriter, r *http.Request) {
                             {{range $j, $allergen := .Allergens}}
                                 {{if $j}}, {{end}}{{$allergen}}
                             {{end}}
+                            {{if .AllergenMatches}}
+                                <span class="allergen-matches">
+                                    ⚠️ {{range $j, $match := .AllergenMatches}}{{if $j}}, {{end}}{{$match.Allergen}} ({{$match.Ingredient}}){{end}}
+                                </span>
+                            {{end}}
                         </td>
                     </tr>
                 {{end}}
@@ -281,26 +451,33 @@ func (s *Server) GetMenuHTMLHandler(w http.ResponseWriter, r *http.Request) {
 </body>
 </html>`))
 
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := tmpl.Execute(w, deliveriesData); err != nil {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, deliveriesData); err != nil {
 		log.Error().Err(err).Msg("Failed to execute template")
 		http.Error(w, "Failed to render page", http.StatusInternalServerError)
 		return
 	}
+
+	writeWithETag(w, r, "text/html; charset=utf-8", http.StatusOK, buf.Bytes())
 }
 
-func (s *Server) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+func (s *Server) respondWithJSON(w http.ResponseWriter, r *http.Request, code int, payload interface{}) {
 	response := APIResponse{
 		Success: code >= 200 && code < 300,
 		Data:    payload,
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(response)
+	body, err := json.Marshal(response)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to encode response")
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+
+	writeWithETag(w, r, "application/json", code, append(body, '\n'))
 }
 
-func (s *Server) respondWithError(w http.ResponseWriter, code int, message string) {
+func (s *Server) respondWithError(w http.ResponseWriter, r *http.Request, code int, message string) {
 	response := APIResponse{
 		Success: false,
 		Error:   message,
@@ -318,9 +495,43 @@ func main() {
 	}
 
 	port := env.GetEnv("PORT", "8080")
-	log.Info().Msgf("Starting server on port %s", port)
-	if err := http.ListenAndServe(":"+port, server.router); err != nil {
-		log.Fatal().Err(err).Msg("Server failed to start")
+	httpServer := &http.Server{
+		Addr:              ":" + port,
+		Handler:           server.handler(),
+		ReadTimeout:       READ_TIMEOUT,
+		ReadHeaderTimeout: READ_HEADER_TIMEOUT,
+		WriteTimeout:      WRITE_TIMEOUT,
+		IdleTimeout:       IDLE_TIMEOUT,
 	}
-}
 
+	httpServer.RegisterOnShutdown(server.events.close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go server.pollChanges(ctx)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Info().Msgf("Starting server on port %s", port)
+		serverErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal().Err(err).Msg("Server failed to start")
+		}
+	case <-ctx.Done():
+		log.Info().Msg("Shutting down server")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), SHUTDOWN_TIMEOUT)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Server shutdown did not complete cleanly")
+		return
+	}
+
+	log.Info().Msg("Server stopped")
+}
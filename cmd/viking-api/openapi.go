@@ -0,0 +1,13 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+func (s *Server) GetOpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	writeWithETag(w, r, "application/json", http.StatusOK, openAPISpec)
+}
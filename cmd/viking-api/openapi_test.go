@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"git.jakub.app/jakub/X/internal/kuchniaviking"
+	"github.com/gorilla/mux"
+)
+
+type schema = map[string]any
+
+func loadSpec(t *testing.T) schema {
+	t.Helper()
+	var doc schema
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		t.Fatalf("can't parse openapi.json: %v", err)
+	}
+	return doc
+}
+
+// TestOpenAPIRoutes fails when a route registered on the router is missing
+// from openapi.json or the document describes an operation that is not
+// routed.
+func TestOpenAPIRoutes(t *testing.T) {
+	auth, err := newAuthenticator(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{router: mux.NewRouter(), auth: auth}
+	s.setupRoutes()
+
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		t.Fatalf("can't parse openapi.json: %v", err)
+	}
+
+	documented := make(map[string]bool)
+	for path, item := range doc.Paths {
+		for method := range item {
+			switch method {
+			case "get", "put", "post", "delete", "options", "head", "patch", "trace":
+				documented[strings.ToUpper(method)+" "+path] = true
+			}
+		}
+	}
+
+	routed := make(map[string]bool)
+	err = s.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			routed[method+" "+path] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("can't walk routes: %v", err)
+	}
+
+	for op := range routed {
+		if !documented[op] {
+			t.Errorf("%s is routed but not documented", op)
+		}
+	}
+	for op := range documented {
+		if !routed[op] {
+			t.Errorf("%s is documented but not routed", op)
+		}
+	}
+}
+
+// TestOpenAPIResponseSchemas encodes the response types, once with every
+// field set and once with zero values, and validates them against the
+// schemas they are documented with.
+func TestOpenAPIResponseSchemas(t *testing.T) {
+	doc := loadSpec(t)
+
+	delivery := filled[DeliveryResponse]()
+	change := filled[kuchniaviking.Change]()
+	change.Type = kuchniaviking.MenuChanged
+	pagination := filled[Pagination]()
+
+	tests := []struct {
+		schema string
+		value  any
+	}{
+		{"DeliveriesResponse", APIResponse{Success: true, Data: []DeliveryResponse{delivery, {}}}},
+		{"ErrorResponse", APIResponse{Error: "bad request"}},
+		{"PurgeResponse", APIResponse{Success: true, Data: map[string]bool{"purged": true}}},
+		{"DeliveriesEnvelope", Envelope{Data: []DeliveryResponse{delivery, {}}, Pagination: &pagination, Warnings: []Warning{filled[Warning](), {}}}},
+		{"DeliveriesEnvelope", Envelope{Data: []DeliveryResponse{}, Pagination: &Pagination{}, Warnings: []Warning{}}},
+		{"PurgeEnvelope", Envelope{Data: map[string]bool{"purged": true}, Warnings: []Warning{}}},
+		{"ErrorEnvelope", Envelope{Error: &EnvelopeError{Code: "invalid_parameter", Message: "bad limit"}, Warnings: []Warning{}}},
+		{"Change", change},
+		{"Change", kuchniaviking.Change{Type: kuchniaviking.DeliveryAdded}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.schema, i), func(t *testing.T) {
+			data, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var value any
+			if err := json.Unmarshal(data, &value); err != nil {
+				t.Fatal(err)
+			}
+
+			ref := schema{"$ref": "#/components/schemas/" + tt.schema}
+			for _, problem := range validate(doc, ref, value, "$") {
+				t.Error(problem)
+			}
+		})
+	}
+}
+
+// validate checks value against the subset of OpenAPI 3.0 schemas the
+// document uses. Objects may only have documented properties.
+func validate(doc, s schema, value any, path string) []string {
+	s = resolve(doc, s)
+
+	if value == nil {
+		if s["nullable"] == true || s["type"] == nil && s["allOf"] == nil {
+			return nil
+		}
+		return []string{path + " is null but not nullable"}
+	}
+
+	var problems []string
+	for _, part := range list(s["allOf"]) {
+		problems = append(problems, validateShape(doc, part.(schema), value, path)...)
+	}
+	problems = append(problems, validateShape(doc, s, value, path)...)
+
+	if props, ok := objectProperties(doc, s); ok {
+		if obj, isObj := value.(map[string]any); isObj {
+			for key := range obj {
+				if _, documented := props[key]; !documented {
+					problems = append(problems, fmt.Sprintf("%s.%s is not documented", path, key))
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// validateShape checks the type, enum, required properties and nested values
+// of a single schema, without allOf.
+func validateShape(doc, s schema, value any, path string) []string {
+	s = resolve(doc, s)
+
+	var problems []string
+	switch s["type"] {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s is %T, want object", path, value)}
+		}
+		for _, name := range list(s["required"]) {
+			if _, ok := obj[name.(string)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s is required", path, name))
+			}
+		}
+		props, _ := s["properties"].(schema)
+		for key, prop := range props {
+			if v, ok := obj[key]; ok {
+				problems = append(problems, validate(doc, prop.(schema), v, path+"."+key)...)
+			}
+		}
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s is %T, want array", path, value)}
+		}
+		if items, ok := s["items"].(schema); ok {
+			for i, v := range arr {
+				problems = append(problems, validate(doc, items, v, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			problems = append(problems, fmt.Sprintf("%s is %T, want string", path, value))
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != float64(int64(n)) {
+			problems = append(problems, fmt.Sprintf("%s is %v, want integer", path, value))
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			problems = append(problems, fmt.Sprintf("%s is %T, want number", path, value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			problems = append(problems, fmt.Sprintf("%s is %T, want boolean", path, value))
+		}
+	}
+
+	if enum := list(s["enum"]); enum != nil {
+		found := false
+		for _, e := range enum {
+			found = found || e == value
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s is %v, not one of %v", path, value, enum))
+		}
+	}
+	return problems
+}
+
+// objectProperties merges the properties of an object schema and its allOf
+// parts, ok is false when the schema doesn't list properties.
+func objectProperties(doc, s schema) (props schema, ok bool) {
+	s = resolve(doc, s)
+	props = schema{}
+	if own, isMap := s["properties"].(schema); isMap {
+		ok = true
+		for k, v := range own {
+			props[k] = v
+		}
+	}
+	for _, part := range list(s["allOf"]) {
+		partProps, partOK := objectProperties(doc, part.(schema))
+		ok = ok || partOK
+		for k, v := range partProps {
+			props[k] = v
+		}
+	}
+	return props, ok
+}
+
+func resolve(doc, s schema) schema {
+	for {
+		ref, ok := s["$ref"].(string)
+		if !ok {
+			return s
+		}
+		var node any = doc
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			node = node.(schema)[part]
+		}
+		s = node.(schema)
+	}
+}
+
+func list(v any) []any {
+	l, _ := v.([]any)
+	return l
+}
+
+// filled returns a T with every exported field set to a non-zero value,
+// slices and maps get one element. Interface fields stay nil.
+func filled[T any]() T {
+	var v T
+	fill(reflect.ValueOf(&v).Elem())
+	return v
+}
+
+func fill(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+		fill(v.Elem())
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fill(v.Index(0))
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+		key, elem := reflect.New(v.Type().Key()).Elem(), reflect.New(v.Type().Elem()).Elem()
+		fill(key)
+		fill(elem)
+		v.SetMapIndex(key, elem)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fill(v.Field(i))
+			}
+		}
+	}
+}
+
+func TestOpenAPISchemaNames(t *testing.T) {
+	doc := loadSpec(t)
+	schemas := doc["components"].(schema)["schemas"].(schema)
+
+	var names []string
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Every referenced schema has to exist.
+	var walk func(node any)
+	walk = func(node any) {
+		switch n := node.(type) {
+		case schema:
+			if ref, ok := n["$ref"].(string); ok && strings.HasPrefix(ref, "#/components/schemas/") {
+				if _, ok := schemas[strings.TrimPrefix(ref, "#/components/schemas/")]; !ok {
+					t.Errorf("%s is referenced but not defined, schemas are %v", ref, names)
+				}
+			}
+			for _, v := range n {
+				walk(v)
+			}
+		case []any:
+			for _, v := range n {
+				walk(v)
+			}
+		}
+	}
+	walk(doc)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.jakub.app/jakub/X/internal/kuchniaviking"
+)
+
+const (
+	defaultDeliveriesLimit = 7
+	maxDeliveriesLimit     = 31
+	queryDateLayout        = "2006-01-02"
+)
+
+type deliveriesQuery struct {
+	kuchniaviking.DeliveryQuery
+	Offset int
+	Meals  []string
+}
+
+func parseDeliveriesQuery(r *http.Request) (deliveriesQuery, error) {
+	values := r.URL.Query()
+	query := deliveriesQuery{
+		DeliveryQuery: kuchniaviking.DeliveryQuery{
+			Limit: defaultDeliveriesLimit,
+		},
+	}
+
+	var err error
+	if v := values.Get("from"); v != "" {
+		query.From, err = time.Parse(queryDateLayout, v)
+		if err != nil {
+			return query, fmt.Errorf("invalid 'from' date %q, expected YYYY-MM-DD", v)
+		}
+	}
+
+	if v := values.Get("to"); v != "" {
+		query.To, err = time.Parse(queryDateLayout, v)
+		if err != nil {
+			return query, fmt.Errorf("invalid 'to' date %q, expected YYYY-MM-DD", v)
+		}
+	}
+
+	if !query.From.IsZero() && !query.To.IsZero() && query.To.Before(query.From) {
+		return query, fmt.Errorf("'to' date must not be before 'from' date")
+	}
+
+	if v := values.Get("limit"); v != "" {
+		query.Limit, err = strconv.Atoi(v)
+		if err != nil || query.Limit < 1 || query.Limit > maxDeliveriesLimit {
+			return query, fmt.Errorf("invalid 'limit' %q, expected a number between 1 and %d", v, maxDeliveriesLimit)
+		}
+	}
+
+	if v := values.Get("offset"); v != "" {
+		query.Offset, err = strconv.Atoi(v)
+		if err != nil || query.Offset < 0 {
+			return query, fmt.Errorf("invalid 'offset' %q, expected a non-negative number", v)
+		}
+	}
+
+	if v := values.Get("includePast"); v != "" {
+		query.IncludePast, err = strconv.ParseBool(v)
+		if err != nil {
+			return query, fmt.Errorf("invalid 'includePast' %q, expected true or false", v)
+		}
+	}
+
+	for _, v := range values["meal"] {
+		for _, meal := range strings.Split(v, ",") {
+			meal = strings.TrimSpace(meal)
+			if meal == "" {
+				return query, fmt.Errorf("invalid 'meal', expected a comma separated list of meal names")
+			}
+			query.Meals = append(query.Meals, meal)
+		}
+	}
+
+	return query, nil
+}
+
+// filterMeals keeps only meals whose MealName matches one of the requested
+// meal types. An empty filter keeps everything.
+func (q deliveriesQuery) filterMeals(meals []kuchniaviking.DeliveryMenuItem) []kuchniaviking.DeliveryMenuItem {
+	if len(q.Meals) == 0 {
+		return meals
+	}
+
+	var filtered []kuchniaviking.DeliveryMenuItem
+	for _, meal := range meals {
+		for _, name := range q.Meals {
+			if strings.EqualFold(meal.MealName, name) {
+				filtered = append(filtered, meal)
+				break
+			}
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"git.jakub.app/jakub/X/internal/kuchniaviking"
+)
+
+func TestParseDeliveriesQuery(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _ := time.Parse(queryDateLayout, s)
+		return d
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    deliveriesQuery
+		wantErr bool
+	}{
+		{"defaults", "", deliveriesQuery{DeliveryQuery: kuchniaviking.DeliveryQuery{Limit: defaultDeliveriesLimit}}, false},
+		{"all", "from=2024-01-02&to=2024-01-05&limit=3&offset=2&includePast=true&meal=Obiad,%20kolacja&meal=lunch", deliveriesQuery{
+			DeliveryQuery: kuchniaviking.DeliveryQuery{From: date("2024-01-02"), To: date("2024-01-05"), Limit: 3, IncludePast: true},
+			Offset:        2,
+			Meals:         []string{"Obiad", "kolacja", "lunch"},
+		}, false},
+		{"same day", "from=2024-01-02&to=2024-01-02", deliveriesQuery{
+			DeliveryQuery: kuchniaviking.DeliveryQuery{From: date("2024-01-02"), To: date("2024-01-02"), Limit: defaultDeliveriesLimit},
+		}, false},
+		{"invalid from", "from=02-01-2024", deliveriesQuery{}, true},
+		{"invalid to", "to=tomorrow", deliveriesQuery{}, true},
+		{"to before from", "from=2024-01-05&to=2024-01-02", deliveriesQuery{}, true},
+		{"zero limit", "limit=0", deliveriesQuery{}, true},
+		{"limit over max", "limit=32", deliveriesQuery{}, true},
+		{"max limit", "limit=31", deliveriesQuery{DeliveryQuery: kuchniaviking.DeliveryQuery{Limit: maxDeliveriesLimit}}, false},
+		{"negative offset", "offset=-1", deliveriesQuery{}, true},
+		{"invalid includePast", "includePast=maybe", deliveriesQuery{}, true},
+		{"empty meal", "meal=obiad,,kolacja", deliveriesQuery{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDeliveriesQuery(httptest.NewRequest("GET", "/api/v1/deliveries?"+tt.query, nil))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseDeliveriesQuery() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.DeliveryQuery != tt.want.DeliveryQuery || got.Offset != tt.want.Offset || !slices.Equal(got.Meals, tt.want.Meals) {
+				t.Errorf("parseDeliveriesQuery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageDeliveries(t *testing.T) {
+	var deliveries []kuchniaviking.Delivery
+	for id := 1; id <= 6; id++ {
+		deliveries = append(deliveries, kuchniaviking.Delivery{DeliveryID: id, Date: time.Date(2024, 1, id, 0, 0, 0, 0, time.UTC).Format(queryDateLayout)})
+	}
+	// Only the even deliveries have a lunch, delivery 5 can't be fetched.
+	menu := func(deliveryID int) ([]kuchniaviking.DeliveryMenuItem, error) {
+		if deliveryID == 5 {
+			return nil, errors.New("unavailable")
+		}
+		meals := []kuchniaviking.DeliveryMenuItem{{MealName: "Obiad"}}
+		if deliveryID%2 == 0 {
+			meals = append(meals, kuchniaviking.DeliveryMenuItem{MealName: "Lunch"})
+		}
+		return meals, nil
+	}
+
+	tests := []struct {
+		name       string
+		query      deliveriesQuery
+		wantIDs    []int
+		wantTotal  int
+		wantFailed int
+	}{
+		{"limit", deliveriesQuery{DeliveryQuery: kuchniaviking.DeliveryQuery{Limit: 2}}, []int{1, 2}, 6, 0},
+		{"offset", deliveriesQuery{DeliveryQuery: kuchniaviking.DeliveryQuery{Limit: 2}, Offset: 3}, []int{4}, 6, 1},
+		{"offset past the end", deliveriesQuery{DeliveryQuery: kuchniaviking.DeliveryQuery{Limit: 2}, Offset: 10}, nil, 6, 0},
+		{"meal filter before limit", deliveriesQuery{DeliveryQuery: kuchniaviking.DeliveryQuery{Limit: 2}, Meals: []string{"lunch"}}, []int{2, 4}, 3, 1},
+		{"meal filter before offset", deliveriesQuery{DeliveryQuery: kuchniaviking.DeliveryQuery{Limit: 2}, Offset: 2, Meals: []string{"lunch"}}, []int{6}, 3, 1},
+		{"no matching meal", deliveriesQuery{DeliveryQuery: kuchniaviking.DeliveryQuery{Limit: 2}, Meals: []string{"kolacja"}}, nil, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := pageDeliveries(deliveries, tt.query, menu)
+
+			var ids []int
+			for _, d := range page.Deliveries {
+				ids = append(ids, d.Delivery.DeliveryID)
+				if len(tt.query.Meals) > 0 && len(d.Meals) != 1 {
+					t.Errorf("delivery %d has %d meals, want only the filtered one", d.Delivery.DeliveryID, len(d.Meals))
+				}
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("deliveries = %v, want %v", ids, tt.wantIDs)
+			}
+			if page.Total != tt.wantTotal {
+				t.Errorf("Total = %d, want %d", page.Total, tt.wantTotal)
+			}
+			if page.Failed != tt.wantFailed {
+				t.Errorf("Failed = %d, want %d", page.Failed, tt.wantFailed)
+			}
+		})
+	}
+}
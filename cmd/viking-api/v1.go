@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Envelope is the response body of every /api/v1 endpoint. Data is null only
+// when Error is set, Warnings is always an array.
+type Envelope struct {
+	Data       any            `json:"data"`
+	Error      *EnvelopeError `json:"error"`
+	RequestID  string         `json:"requestId"`
+	Pagination *Pagination    `json:"pagination,omitempty"`
+	Warnings   []Warning      `json:"warnings"`
+}
+
+type EnvelopeError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type Pagination struct {
+	Limit      int  `json:"limit"`
+	Offset     int  `json:"offset"`
+	Total      int  `json:"total"`
+	NextOffset *int `json:"nextOffset"`
+}
+
+// Warning reports a partial failure that did not prevent the response.
+type Warning struct {
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	DeliveryID int    `json:"deliveryId,omitempty"`
+}
+
+func respondV1(w http.ResponseWriter, r *http.Request, code int, envelope Envelope) {
+	if envelope.Warnings == nil {
+		envelope.Warnings = []Warning{}
+	}
+
+	// The request ID differs on every call, keep it out of the ETag.
+	stable, err := json.Marshal(envelope)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to encode response")
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+
+	envelope.RequestID = requestIDFromContext(r.Context())
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to encode response")
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+
+	writeWithTag(w, r, "application/json", code, append(body, '\n'), computeETag(stable))
+}
+
+func respondV1Error(w http.ResponseWriter, r *http.Request, code int, errCode, message string, warnings []Warning) {
+	respondV1(w, r, code, Envelope{
+		Error:    &EnvelopeError{Code: errCode, Message: message},
+		Warnings: warnings,
+	})
+}
+
+func (s *Server) GetDeliveriesV1Handler(w http.ResponseWriter, r *http.Request) {
+	query, err := parseDeliveriesQuery(r)
+	if err != nil {
+		respondV1Error(w, r, http.StatusBadRequest, "invalid_parameter", err.Error(), nil)
+		return
+	}
+
+	page, err := s.loadDeliveries(query)
+	if err != nil {
+		var apiErr *apiError
+		if errors.As(err, &apiErr) {
+			respondV1Error(w, r, apiErr.code, apiErr.errCode, apiErr.message, nil)
+			return
+		}
+		respondV1Error(w, r, http.StatusInternalServerError, "internal_error", err.Error(), nil)
+		return
+	}
+
+	if page.Failed > 0 && len(page.Deliveries) == 0 {
+		respondV1Error(w, r, http.StatusBadGateway, "upstream_unavailable", "Failed to get the menu of every requested delivery", page.Warnings)
+		return
+	}
+
+	data := []DeliveryResponse{}
+	for _, delivery := range page.Deliveries {
+		data = append(data, DeliveryResponse{
+			Date:         delivery.Delivery.Date,
+			DeliveryID:   delivery.Delivery.DeliveryID,
+			Meals:        delivery.Meals,
+			AllergyMeals: s.allergenProfile.FilterMeals(delivery.Meals),
+		})
+	}
+
+	pagination := &Pagination{
+		Limit:  query.Limit,
+		Offset: query.Offset,
+		Total:  page.Total,
+	}
+	if next := query.Offset + query.Limit; next < page.Total {
+		pagination.NextOffset = &next
+	}
+
+	respondV1(w, r, http.StatusOK, Envelope{
+		Data:       data,
+		Pagination: pagination,
+		Warnings:   page.Warnings,
+	})
+}
+
+func (s *Server) PurgeCacheV1Handler(w http.ResponseWriter, r *http.Request) {
+	if err := s.cache.Purge(r.Context()); err != nil {
+		log.Error().Err(err).Msg("Failed to purge cache")
+		respondV1Error(w, r, http.StatusInternalServerError, "cache_error", "Failed to purge cache", nil)
+		return
+	}
+
+	log.Info().Msg("Cache purged")
+	respondV1(w, r, http.StatusOK, Envelope{Data: map[string]bool{"purged": true}})
+}
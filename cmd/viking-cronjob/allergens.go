@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"git.jakub.app/jakub/X/internal/kuchniaviking"
+	"git.jakub.app/jakub/X/internal/notify"
+	"github.com/rs/zerolog/log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// notifiedAlert remembers an allergen match that was already sent, keyed by
+// alertKey, so following runs only alert about new or changed matches.
+// PendingSinks are the sinks that failed to get it and are retried.
+type notifiedAlert struct {
+	DeliveryID   int       `json:"deliveryId"`
+	Date         string    `json:"date"`
+	MenuMealID   int       `json:"menuMealId"`
+	Allergen     string    `json:"allergen"`
+	Ingredients  []string  `json:"ingredients"`
+	NotifiedAt   time.Time `json:"notifiedAt"`
+	PendingSinks []string  `json:"pendingSinks,omitempty"`
+}
+
+func alertKey(deliveryID, menuMealID int, allergen string) string {
+	return fmt.Sprintf("%d/%d/%s", deliveryID, menuMealID, allergen)
+}
+
+// ingredientsByAllergen groups the matched ingredient names of a meal by
+// allergen, sorted so they compare stably between runs.
+func ingredientsByAllergen(meal kuchniaviking.AllergyMeal) map[string][]string {
+	grouped := make(map[string][]string)
+	for _, match := range meal.Matches {
+		grouped[match.Allergen] = append(grouped[match.Allergen], match.Ingredient)
+	}
+	for _, ingredients := range grouped {
+		sort.Strings(ingredients)
+	}
+	return grouped
+}
+
+// unnotifiedMeals returns the meals with at least one allergen that was not
+// alerted yet or whose matched ingredients changed since.
+func unnotifiedMeals(notified map[string]notifiedAlert, delivery kuchniaviking.Delivery, meals []kuchniaviking.AllergyMeal) []kuchniaviking.AllergyMeal {
+	var result []kuchniaviking.AllergyMeal
+	for _, meal := range meals {
+		for allergen, ingredients := range ingredientsByAllergen(meal) {
+			prev, ok := notified[alertKey(delivery.DeliveryID, meal.MenuMealID, allergen)]
+			if !ok || strings.Join(prev.Ingredients, "\n") != strings.Join(ingredients, "\n") {
+				result = append(result, meal)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// pendingAlert retries already notified meals through the sinks that failed
+// to get them.
+type pendingAlert struct {
+	sinks []string
+	meals []kuchniaviking.AllergyMeal
+}
+
+// pendingMeals returns the already notified meals that some sinks failed to
+// get, grouped by those sinks so every retry skips the sinks that got it.
+func pendingMeals(notified map[string]notifiedAlert, delivery kuchniaviking.Delivery, meals []kuchniaviking.AllergyMeal) []pendingAlert {
+	var result []pendingAlert
+	for _, meal := range meals {
+		var sinks []string
+		for allergen := range ingredientsByAllergen(meal) {
+			for _, sink := range notified[alertKey(delivery.DeliveryID, meal.MenuMealID, allergen)].PendingSinks {
+				if !slices.Contains(sinks, sink) {
+					sinks = append(sinks, sink)
+				}
+			}
+		}
+		if len(sinks) == 0 {
+			continue
+		}
+		sort.Strings(sinks)
+
+		i := slices.IndexFunc(result, func(p pendingAlert) bool {
+			return slices.Equal(p.sinks, sinks)
+		})
+		if i < 0 {
+			result = append(result, pendingAlert{sinks: sinks})
+			i = len(result) - 1
+		}
+		result[i].meals = append(result[i].meals, meal)
+	}
+	return result
+}
+
+func markNotified(notified map[string]notifiedAlert, delivery kuchniaviking.Delivery, meals []kuchniaviking.AllergyMeal, at time.Time, pending []string) {
+	for _, meal := range meals {
+		for allergen, ingredients := range ingredientsByAllergen(meal) {
+			notified[alertKey(delivery.DeliveryID, meal.MenuMealID, allergen)] = notifiedAlert{
+				DeliveryID:   delivery.DeliveryID,
+				Date:         delivery.Date,
+				MenuMealID:   meal.MenuMealID,
+				Allergen:     allergen,
+				Ingredients:  ingredients,
+				NotifiedAt:   at,
+				PendingSinks: pending,
+			}
+		}
+	}
+}
+
+// failedSinks reports whether an alert reached at least one sink despite err
+// and which sinks it didn't reach.
+func failedSinks(err error) (failed []string, delivered bool) {
+	if err == nil {
+		return nil, true
+	}
+	var deliveryErr *notify.DeliveryError
+	if errors.As(err, &deliveryErr) && len(deliveryErr.Delivered) > 0 {
+		return deliveryErr.Failed, true
+	}
+	return nil, false
+}
+
+// pruneNotified forgets alerts for deliveries dated before today.
+func pruneNotified(s *state, now time.Time) {
+	today := now.Format("2006-01-02")
+	for key, alert := range s.NotifiedAlerts {
+		if alert.Date < today {
+			delete(s.NotifiedAlerts, key)
+		}
+	}
+	for id, date := range s.RemindedDeliveries {
+		if date < today {
+			delete(s.RemindedDeliveries, id)
+		}
+	}
+}
+
+func allergenAlert(kind, title, description, date string, meals []kuchniaviking.AllergyMeal) notify.Alert {
+	var fields []notify.Field
+	for _, meal := range meals {
+		fields = append(fields, notify.Field{
+			Name:   "Date",
+			Value:  date,
+			Inline: false,
+		})
+
+		fields = append(fields, notify.Field{
+			Name:   "Meal",
+			Value:  meal.MenuMealName,
+			Inline: false,
+		})
+
+		var allergenIngredients []string
+		for _, match := range meal.Matches {
+			allergenIngredients = append(allergenIngredients, fmt.Sprintf("%s (%s)", match.Ingredient, match.Allergen))
+		}
+
+		fields = append(fields, notify.Field{
+			Name:   "Allergen Ingredients",
+			Value:  strings.Join(allergenIngredients, "\n"),
+			Inline: false,
+		})
+	}
+
+	return notify.Alert{
+		Kind:        kind,
+		Title:       title,
+		Description: description,
+		Color:       0xFF0000,
+		Fields:      fields,
+	}
+}
+
+func checkAllergens(ctx context.Context, d deps, report *runReport) error {
+	st := d.state
+
+	// Without --days the next three deliveries are checked.
+	from := tomorrow()
+	query := kuchniaviking.DeliveryQuery{From: from, Limit: 3}
+	if d.days > 0 {
+		query = kuchniaviking.DeliveryQuery{From: from, To: from.AddDate(0, 0, d.days-1)}
+	}
+
+	kv, nearestDeliveries, err := fetchDeliveries(query)
+	if err != nil {
+		return err
+	}
+
+	if err := st.update(func(s *state) {
+		pruneNotified(s, time.Now())
+	}); err != nil {
+		log.Error().Err(err).Msg("can't persist state")
+	}
+
+	for _, nearestDelivery := range nearestDeliveries {
+		if nearestDelivery.Deleted {
+			continue
+		}
+
+		deliveryInfo, err := kv.GetDeliveryInfo(nearestDelivery.DeliveryID)
+		if err != nil {
+			log.Error().Err(err).Int("deliveryId", nearestDelivery.DeliveryID).Msg("can't get delivery info")
+			deliveryErrorsTotal.Inc()
+			report.add(nearestDelivery.DeliveryID, nearestDelivery.Date, deliveryFailed, err)
+			continue
+		}
+		deliveriesCheckedTotal.Inc()
+
+		for _, meal := range deliveryInfo.DeliveryMenuMeal {
+			log.Debug().
+				Str("date", nearestDelivery.Date).
+				Str("mealName", meal.MealName).
+				Str("menuMealName", meal.MenuMealName).
+				Interface("ingredients", meal.Ingredients).
+				Msg("checking meal")
+		}
+
+		// Meals that are new or changed go to every sink, meals that some
+		// sinks missed last time only to those.
+		var allergyMeals []kuchniaviking.AllergyMeal
+		var retries []pendingAlert
+		st.view(func(s *state) {
+			meals := d.profile.FilterMeals(deliveryInfo.DeliveryMenuMeal)
+			allergyMeals = unnotifiedMeals(s.NotifiedAlerts, nearestDelivery, meals)
+			meals = slices.DeleteFunc(meals, func(meal kuchniaviking.AllergyMeal) bool {
+				return slices.ContainsFunc(allergyMeals, func(m kuchniaviking.AllergyMeal) bool {
+					return m.MenuMealID == meal.MenuMealID
+				})
+			})
+			retries = pendingMeals(s.NotifiedAlerts, nearestDelivery, meals)
+		})
+		log.Debug().
+			Str("date", nearestDelivery.Date).
+			Int("allergyMeals", len(allergyMeals)).
+			Int("retries", len(retries)).
+			Msg("delivery checked")
+
+		if len(allergyMeals) == 0 && len(retries) == 0 {
+			report.add(nearestDelivery.DeliveryID, nearestDelivery.Date, deliveryOK, nil)
+			continue
+		}
+
+		var errs []error
+		if len(allergyMeals) > 0 {
+			errs = append(errs, sendAllergenAlert(ctx, d, nearestDelivery, allergyMeals, nil))
+		}
+		for _, retry := range retries {
+			errs = append(errs, sendAllergenAlert(ctx, d, nearestDelivery, retry.meals, retry.sinks))
+		}
+		if err := errors.Join(errs...); err != nil {
+			report.add(nearestDelivery.DeliveryID, nearestDelivery.Date, deliveryFailed, err)
+			continue
+		}
+		report.add(nearestDelivery.DeliveryID, nearestDelivery.Date, deliveryAlerted, nil)
+	}
+
+	return nil
+}
+
+// sendAllergenAlert alerts about meals, only through sinks when it is set.
+// The meals are remembered as notified once any sink got the alert, together
+// with the sinks that failed so the next run retries just those.
+func sendAllergenAlert(ctx context.Context, d deps, delivery kuchniaviking.Delivery, meals []kuchniaviking.AllergyMeal, sinks []string) error {
+	alert := allergenAlert(
+		kindAllergen,
+		"⚠️ Allergen Alert",
+		fmt.Sprintf("Found %d meals containing allergens!", len(meals)),
+		delivery.Date,
+		meals,
+	)
+	alert.Sinks = sinks
+
+	err := d.notifier.Notify(ctx, alert)
+	if sinks != nil && errors.Is(err, notify.ErrNoRoute) {
+		// The sinks that missed the alert were removed from the routes since,
+		// there's nothing left to retry.
+		log.Warn().Err(err).Strs("sinks", sinks).Msg("dropping allergen alert retry")
+		if err := d.state.update(func(s *state) {
+			markNotified(s.NotifiedAlerts, delivery, meals, time.Now(), nil)
+		}); err != nil {
+			log.Error().Err(err).Msg("can't persist notified alerts")
+		}
+		return nil
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("failed to send allergen alert")
+		alertFailuresTotal.WithLabelValues(kindAllergen).Inc()
+	}
+	failed, delivered := failedSinks(err)
+	if !delivered {
+		return err
+	}
+	alertsSentTotal.WithLabelValues(kindAllergen).Inc()
+
+	if err := d.state.update(func(s *state) {
+		markNotified(s.NotifiedAlerts, delivery, meals, time.Now(), failed)
+	}); err != nil {
+		log.Error().Err(err).Msg("can't persist notified alerts")
+	}
+	return err
+}
+
+// remindAllergens sends one reminder per delivery on the evening before it
+// arrives, listing every meal of tomorrow's menu that contains an allergen.
+func remindAllergens(ctx context.Context, d deps, report *runReport) error {
+	st := d.state
+
+	from := tomorrow()
+	kv, deliveries, err := fetchDeliveries(kuchniaviking.DeliveryQuery{From: from, To: from})
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		if delivery.Deleted {
+			continue
+		}
+
+		var reminded bool
+		st.view(func(s *state) {
+			_, reminded = s.RemindedDeliveries[delivery.DeliveryID]
+		})
+		if reminded {
+			continue
+		}
+
+		deliveryInfo, err := kv.GetDeliveryInfo(delivery.DeliveryID)
+		if err != nil {
+			log.Error().Err(err).Int("deliveryId", delivery.DeliveryID).Msg("can't get delivery info")
+			deliveryErrorsTotal.Inc()
+			report.add(delivery.DeliveryID, delivery.Date, deliveryFailed, err)
+			continue
+		}
+		deliveriesCheckedTotal.Inc()
+
+		allergyMeals := d.profile.FilterMeals(deliveryInfo.DeliveryMenuMeal)
+		if len(allergyMeals) == 0 {
+			report.add(delivery.DeliveryID, delivery.Date, deliveryOK, nil)
+			continue
+		}
+
+		alert := allergenAlert(
+			kindReminder,
+			"⏰ Allergen Reminder",
+			fmt.Sprintf("Tomorrow's delivery has %d meals containing allergens", len(allergyMeals)),
+			delivery.Date,
+			allergyMeals,
+		)
+		// A reminder that reached some sinks isn't sent again, the sinks it
+		// missed are retried with the allergen alerts instead.
+		err = d.notifier.Notify(ctx, alert)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to send allergen reminder")
+			alertFailuresTotal.WithLabelValues(kindReminder).Inc()
+		}
+		failed, delivered := failedSinks(err)
+		if !delivered {
+			report.add(delivery.DeliveryID, delivery.Date, deliveryFailed, err)
+			continue
+		}
+		alertsSentTotal.WithLabelValues(kindReminder).Inc()
+		if err != nil {
+			report.add(delivery.DeliveryID, delivery.Date, deliveryFailed, err)
+		} else {
+			report.add(delivery.DeliveryID, delivery.Date, deliveryAlerted, nil)
+		}
+
+		if err := st.update(func(s *state) {
+			s.RemindedDeliveries[delivery.DeliveryID] = delivery.Date
+			markNotified(s.NotifiedAlerts, delivery, allergyMeals, time.Now(), failed)
+		}); err != nil {
+			log.Error().Err(err).Msg("can't persist reminder")
+		}
+	}
+
+	return nil
+}
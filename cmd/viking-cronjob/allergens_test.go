@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"git.jakub.app/jakub/X/internal/kuchniaviking"
+	"git.jakub.app/jakub/X/internal/notify"
+)
+
+func allergyMeal(id int, matches ...kuchniaviking.AllergenMatch) kuchniaviking.AllergyMeal {
+	meal := kuchniaviking.AllergyMeal{Matches: matches}
+	meal.MenuMealID = id
+	return meal
+}
+
+func TestPendingMeals(t *testing.T) {
+	delivery := kuchniaviking.Delivery{DeliveryID: 1, Date: "2026-10-20"}
+	milk := allergyMeal(10, kuchniaviking.AllergenMatch{Allergen: "mleko", Ingredient: "ser"})
+	nuts := allergyMeal(11, kuchniaviking.AllergenMatch{Allergen: "orzechy", Ingredient: "orzech"})
+	gluten := allergyMeal(12, kuchniaviking.AllergenMatch{Allergen: "gluten", Ingredient: "mąka"})
+	eggs := allergyMeal(13, kuchniaviking.AllergenMatch{Allergen: "jaja", Ingredient: "jajko"})
+
+	// Each alert reached some of the sinks.
+	notified := make(map[string]notifiedAlert)
+	markNotified(notified, delivery, []kuchniaviking.AllergyMeal{milk}, time.Now(), []string{"email"})
+	markNotified(notified, delivery, []kuchniaviking.AllergyMeal{nuts}, time.Now(), nil)
+	markNotified(notified, delivery, []kuchniaviking.AllergyMeal{gluten}, time.Now(), []string{"push"})
+	markNotified(notified, delivery, []kuchniaviking.AllergyMeal{eggs}, time.Now(), []string{"email"})
+
+	meals := []kuchniaviking.AllergyMeal{milk, nuts, gluten, eggs}
+	if got := unnotifiedMeals(notified, delivery, meals); len(got) != 0 {
+		t.Errorf("unnotifiedMeals() = %v, want none", got)
+	}
+
+	retries := pendingMeals(notified, delivery, meals)
+	want := []struct {
+		sinks []string
+		meals []int
+	}{
+		{[]string{"email"}, []int{milk.MenuMealID, eggs.MenuMealID}},
+		{[]string{"push"}, []int{gluten.MenuMealID}},
+	}
+	if len(retries) != len(want) {
+		t.Fatalf("pendingMeals() = %+v, want %d retries", retries, len(want))
+	}
+	for i, w := range want {
+		var ids []int
+		for _, meal := range retries[i].meals {
+			ids = append(ids, meal.MenuMealID)
+		}
+		if !slices.Equal(retries[i].sinks, w.sinks) || !slices.Equal(ids, w.meals) {
+			t.Errorf("retry %d = %v %v, want %v %v", i, retries[i].sinks, ids, w.sinks, w.meals)
+		}
+	}
+
+	for _, retry := range retries {
+		markNotified(notified, delivery, retry.meals, time.Now(), nil)
+	}
+	if retries := pendingMeals(notified, delivery, meals); len(retries) != 0 {
+		t.Errorf("pendingMeals() after retry = %+v, want none", retries)
+	}
+}
+
+func TestSendAllergenAlertRetriesFailedSinks(t *testing.T) {
+	st, err := loadState(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	delivery := kuchniaviking.Delivery{DeliveryID: 1, Date: "2026-10-20"}
+	milk := allergyMeal(10, kuchniaviking.AllergenMatch{Allergen: "mleko", Ingredient: "ser"})
+	gluten := allergyMeal(12, kuchniaviking.AllergenMatch{Allergen: "gluten", Ingredient: "mąka"})
+	meals := []kuchniaviking.AllergyMeal{milk, gluten}
+	st.update(func(s *state) {
+		markNotified(s.NotifiedAlerts, delivery, []kuchniaviking.AllergyMeal{milk}, time.Now(), []string{"email"})
+		markNotified(s.NotifiedAlerts, delivery, []kuchniaviking.AllergyMeal{gluten}, time.Now(), []string{"push"})
+	})
+
+	sinks := map[string]*fakeSink{"discord": {}, "email": {}, "push": {}}
+	router := notify.NewRouter()
+	for name, sink := range sinks {
+		router.AddSink(name, sink)
+	}
+	router.Route(kindAllergen, "discord", "email", "push")
+
+	d := deps{state: st, notifier: router}
+	var retries []pendingAlert
+	st.view(func(s *state) {
+		retries = pendingMeals(s.NotifiedAlerts, delivery, meals)
+	})
+	for _, retry := range retries {
+		if err := sendAllergenAlert(context.Background(), d, delivery, retry.meals, retry.sinks); err != nil {
+			t.Fatalf("sendAllergenAlert() = %v", err)
+		}
+	}
+
+	// Every sink gets only the alert it missed.
+	for name, want := range map[string]int{"discord": 0, "email": 1, "push": 1} {
+		if got := len(sinks[name].alerts); got != want {
+			t.Errorf("%s got %d alerts, want %d", name, got, want)
+		}
+	}
+	st.view(func(s *state) {
+		if retries := pendingMeals(s.NotifiedAlerts, delivery, meals); len(retries) != 0 {
+			t.Errorf("pendingMeals() after retry = %+v, want none", retries)
+		}
+	})
+}
+
+func TestFailedSinks(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantFailed    []string
+		wantDelivered bool
+	}{
+		{"delivered", nil, nil, true},
+		{"partial", &notify.DeliveryError{Delivered: []string{"discord"}, Failed: []string{"email"}}, []string{"email"}, true},
+		{"all failed", &notify.DeliveryError{Failed: []string{"discord", "email"}}, nil, false},
+		{"no route", errors.New("notify: no sink routed"), nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failed, delivered := failedSinks(tt.err)
+			if !slices.Equal(failed, tt.wantFailed) || delivered != tt.wantDelivered {
+				t.Errorf("failedSinks() = %v, %v; want %v, %v", failed, delivered, tt.wantFailed, tt.wantDelivered)
+			}
+		})
+	}
+}
+
+type fakeSink struct {
+	alerts []notify.Alert
+}
+
+func (f *fakeSink) Notify(ctx context.Context, alert notify.Alert) error {
+	f.alerts = append(f.alerts, alert)
+	return nil
+}
+
+func TestSendAllergenAlertUnroutedRetry(t *testing.T) {
+	st, err := loadState(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	delivery := kuchniaviking.Delivery{DeliveryID: 1, Date: "2026-10-20"}
+	milk := allergyMeal(10, kuchniaviking.AllergenMatch{Allergen: "mleko", Ingredient: "ser"})
+	st.update(func(s *state) {
+		markNotified(s.NotifiedAlerts, delivery, []kuchniaviking.AllergyMeal{milk}, time.Now(), []string{"email"})
+	})
+
+	// The e-mail sink was dropped from the routes after the failed send.
+	discord := &fakeSink{}
+	router := notify.NewRouter()
+	router.AddSink("discord", discord)
+	router.Route(kindAllergen, "discord")
+
+	d := deps{state: st, notifier: router}
+	if err := sendAllergenAlert(context.Background(), d, delivery, []kuchniaviking.AllergyMeal{milk}, []string{"email"}); err != nil {
+		t.Fatalf("sendAllergenAlert() = %v", err)
+	}
+	if len(discord.alerts) != 0 {
+		t.Errorf("discord got %d alerts, want none", len(discord.alerts))
+	}
+	st.view(func(s *state) {
+		if retries := pendingMeals(s.NotifiedAlerts, delivery, []kuchniaviking.AllergyMeal{milk}); len(retries) != 0 {
+			t.Errorf("pendingMeals() = %+v, want the retry dropped", retries)
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"git.jakub.app/jakub/X/cmd/layla/modules/discord"
+	"git.jakub.app/jakub/X/internal/env"
+	"git.jakub.app/jakub/X/internal/kuchniaviking"
+	"git.jakub.app/jakub/X/internal/notify"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	CHANGES_DAYS = env.GetEnvAsInt("CRONJOB_CHANGES_DAYS", 14)
+)
+
+// detectChanges compares the upcoming deliveries with the snapshot stored by
+// the previous run and reports the differences. The first run only stores
+// the baseline. Deliveries whose menu could not be fetched are reported as
+// failed, their menu changes show up on a later run.
+func detectChanges(ctx context.Context, d deps, report *runReport) error {
+	st := d.state
+
+	kv, err := kuchniaviking.New()
+	if err != nil {
+		log.Error().Err(err).Msg("can't initialize kuchnia vikinga")
+		return err
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	snapshot, err := kuchniaviking.TakeSnapshot(kv, kuchniaviking.DeliveryQuery{
+		From: today,
+		To:   today.AddDate(0, 0, d.horizon(CHANGES_DAYS)),
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("can't take delivery snapshot")
+		return err
+	}
+
+	for _, delivery := range sortedDeliveries(snapshot) {
+		var err error
+		if !delivery.Deleted && !delivery.MenuKnown {
+			err = errors.New("menu unavailable")
+			deliveryErrorsTotal.Inc()
+		}
+		report.add(delivery.DeliveryID, delivery.Date, deliveryOK, err)
+	}
+
+	previous := storedSnapshot(st)
+
+	// The snapshot is kept once the changes reached any sink, so they aren't
+	// sent again to the sinks that got them.
+	var notifyErr error
+	if previous != nil {
+		changes := kuchniaviking.DiffSnapshots(*previous, snapshot)
+		if len(changes) > 0 {
+			notifyErr = d.notifier.Notify(ctx, changesAlert(changes))
+			if notifyErr != nil {
+				log.Error().Err(notifyErr).Msg("failed to send change alert")
+				alertFailuresTotal.WithLabelValues(kindChanges).Inc()
+			}
+			if _, delivered := failedSinks(notifyErr); !delivered {
+				return notifyErr
+			}
+			alertsSentTotal.WithLabelValues(kindChanges).Inc()
+		}
+		log.Info().Int("changes", len(changes)).Msg("change detection finished")
+	} else {
+		log.Info().Msg("stored first delivery snapshot")
+	}
+
+	if err := st.update(func(s *state) {
+		s.Snapshot = &snapshot
+	}); err != nil {
+		return err
+	}
+	return notifyErr
+}
+
+// storedSnapshot returns the snapshot to diff against, if any. Snapshots
+// stored without their window can't tell deliveries that entered the window
+// from added ones and only serve as a baseline to replace.
+func storedSnapshot(st *stateStore) *kuchniaviking.Snapshot {
+	var previous *kuchniaviking.Snapshot
+	st.view(func(s *state) {
+		previous = s.Snapshot
+	})
+	if previous != nil && !previous.HasWindow() {
+		log.Info().Msg("stored snapshot has no window, replacing it without diffing")
+		return nil
+	}
+	return previous
+}
+
+func changesAlert(changes []kuchniaviking.Change) notify.Alert {
+	const maxFields = 25
+
+	var fields []notify.Field
+	for i, change := range changes {
+		if i == maxFields-1 && len(changes) > maxFields {
+			fields = append(fields, notify.Field{
+				Name:  "…",
+				Value: fmt.Sprintf("and %d more changes", len(changes)-i),
+			})
+			break
+		}
+		fields = append(fields, notify.Field{
+			Name:  fmt.Sprintf("%s — %s", change.Date, change.Type),
+			Value: describeChange(change),
+		})
+	}
+
+	return notify.Alert{
+		Kind:        kindChanges,
+		Title:       "🔄 Delivery Changes",
+		Description: fmt.Sprintf("Found %d changes in upcoming deliveries", len(changes)),
+		Color:       0x3498DB,
+		Fields:      fields,
+	}
+}
+
+func sortedDeliveries(snapshot kuchniaviking.Snapshot) []kuchniaviking.DeliverySnapshot {
+	deliveries := make([]kuchniaviking.DeliverySnapshot, 0, len(snapshot.Deliveries))
+	for _, delivery := range snapshot.Deliveries {
+		deliveries = append(deliveries, delivery)
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].Date < deliveries[j].Date
+	})
+	return deliveries
+}
+
+func describeChange(change kuchniaviking.Change) string {
+	switch change.Type {
+	case kuchniaviking.DeliveryAdded:
+		return fmt.Sprintf("Delivery %d was added", change.DeliveryID)
+	case kuchniaviking.DeliveryDeleted:
+		return fmt.Sprintf("Delivery %d was deleted", change.DeliveryID)
+	}
+
+	var lines []string
+	for _, meal := range change.Meals {
+		switch {
+		case meal.Before == nil:
+			lines = append(lines, fmt.Sprintf("**%s**: + %s", meal.MealName, meal.After.MenuMealName))
+		case meal.After == nil:
+			lines = append(lines, fmt.Sprintf("**%s**: − %s", meal.MealName, meal.Before.MenuMealName))
+		default:
+			lines = append(lines, fmt.Sprintf("**%s**: %s → %s", meal.MealName, meal.Before.MenuMealName, meal.After.MenuMealName))
+		}
+	}
+
+	return discord.Truncate(strings.Join(lines, "\n"), discord.EmbedFieldValueLimit)
+}
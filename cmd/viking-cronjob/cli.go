@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"git.jakub.app/jakub/X/internal/kuchniaviking"
+	"git.jakub.app/jakub/X/internal/notify"
+	"github.com/rs/zerolog"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+type options struct {
+	dryRun  bool
+	days    int
+	profile string
+	output  string
+	report  string
+	verbose bool
+}
+
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, d deps, opts options) error
+}
+
+func commands() []command {
+	return []command{
+		{"check-allergens", "alert about allergens in the upcoming deliveries", jobCommand("allergens")},
+		{"reminder", "remind about allergens in tomorrow's delivery", jobCommand("reminder")},
+		{"digest", "post tomorrow's menu", jobCommand("digest")},
+		{"changes", "alert about menu and delivery changes since the last run", jobCommand("changes")},
+		{"snapshot", "print the upcoming deliveries and their menus", snapshotCommand},
+		{"diff", "print the changes since the stored snapshot without storing a new one", diffCommand},
+		{"daemon", "run the jobs on their schedules", daemonCommand},
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: viking-cronjob [command] [flags]\n\nCommands:\n")
+	for _, c := range commands() {
+		fmt.Fprintf(w, "  %-16s %s\n", c.name, c.usage)
+	}
+	fmt.Fprintf(w, "\nWithout a command check-allergens runs, or daemon when CRONJOB_DAEMON is set.\n")
+	fmt.Fprintf(w, "Run 'viking-cronjob <command> -h' for the flags.\n")
+}
+
+// parseArgs picks the command from args and parses its flags.
+func parseArgs(args []string) (command, options, error) {
+	name := "check-allergens"
+	if DAEMON {
+		name = "daemon"
+	}
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	var cmd command
+	for _, c := range commands() {
+		if c.name == name {
+			cmd = c
+		}
+	}
+	if cmd.run == nil {
+		usage(os.Stderr)
+		return cmd, options{}, fmt.Errorf("unknown command %q", name)
+	}
+
+	var opts options
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "print alerts to stdout instead of sending them and don't update the state file")
+	fs.IntVar(&opts.days, "days", 0, "number of days to look ahead, 0 keeps the command default")
+	fs.StringVar(&opts.profile, "profile", strings.Join(kuchniaviking.VIKING_ALLERGENS, ","), "comma separated allergens to check")
+	fs.StringVar(&opts.output, "output", outputText, "output format of printed alerts and results, text or json")
+	fs.StringVar(&opts.report, "report", "", "write a JSON run report to this file, - for stdout")
+	fs.BoolVar(&opts.verbose, "v", false, "enable debug logging")
+	fs.Usage = func() {
+		usage(fs.Output())
+		fmt.Fprintf(fs.Output(), "\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return cmd, opts, err
+	}
+
+	if fs.NArg() > 0 {
+		return cmd, opts, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if opts.days < 0 {
+		return cmd, opts, fmt.Errorf("invalid --days %d, expected a non-negative number", opts.days)
+	}
+	if opts.output != outputText && opts.output != outputJSON {
+		return cmd, opts, fmt.Errorf("invalid --output %q, expected text or json", opts.output)
+	}
+	return cmd, opts, nil
+}
+
+// newDeps loads the state and sets up notifications for opts. A dry run keeps
+// the state in memory and prints alerts to stdout.
+func newDeps(opts options) (deps, error) {
+	st, err := loadState(STATE_FILE)
+	if err != nil {
+		return deps{}, fmt.Errorf("can't load state from %s: %w", STATE_FILE, err)
+	}
+	st.readOnly = opts.dryRun
+
+	var notifier notify.Notifier = &notify.Writer{W: os.Stdout, JSON: opts.output == outputJSON}
+	if !opts.dryRun {
+		notifier, err = newNotifier()
+		if err != nil {
+			return deps{}, fmt.Errorf("can't configure notifications: %w", err)
+		}
+	}
+
+	return deps{
+		state:    st,
+		notifier: notifier,
+		profile:  kuchniaviking.NewAllergenProfile(strings.Split(opts.profile, ",")),
+		days:     opts.days,
+		dryRun:   opts.dryRun,
+	}, nil
+}
+
+// jobCommand runs the named scheduled job once and writes its report when
+// --report is given.
+func jobCommand(name string) func(ctx context.Context, d deps, opts options) error {
+	return func(ctx context.Context, d deps, opts options) error {
+		for _, j := range jobs() {
+			if j.name != name {
+				continue
+			}
+
+			report := runJob(ctx, d, j)
+			if opts.report != "" {
+				if err := report.write(opts.report); err != nil {
+					return fmt.Errorf("can't write run report: %w", err)
+				}
+			}
+			return report.err()
+		}
+		return fmt.Errorf("unknown job %q", name)
+	}
+}
+
+func daemonCommand(ctx context.Context, d deps, opts options) error {
+	if opts.dryRun {
+		return errors.New("daemon can't run with --dry-run")
+	}
+	return runDaemon(ctx, d)
+}
+
+func takeSnapshot(d deps) (kuchniaviking.Snapshot, error) {
+	kv, err := kuchniaviking.New()
+	if err != nil {
+		return kuchniaviking.Snapshot{}, fmt.Errorf("can't initialize kuchnia vikinga: %w", err)
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return kuchniaviking.TakeSnapshot(kv, kuchniaviking.DeliveryQuery{
+		From: today,
+		To:   today.AddDate(0, 0, d.horizon(CHANGES_DAYS)),
+	})
+}
+
+func snapshotCommand(ctx context.Context, d deps, opts options) error {
+	snapshot, err := takeSnapshot(d)
+	if err != nil {
+		return err
+	}
+
+	if opts.output == outputJSON {
+		return printJSON(snapshot)
+	}
+
+	for _, delivery := range sortedDeliveries(snapshot) {
+		switch {
+		case delivery.Deleted:
+			fmt.Printf("%s  #%d  deleted\n", delivery.Date, delivery.DeliveryID)
+			continue
+		case !delivery.MenuKnown:
+			fmt.Printf("%s  #%d  menu unavailable\n", delivery.Date, delivery.DeliveryID)
+			continue
+		}
+
+		fmt.Printf("%s  #%d\n", delivery.Date, delivery.DeliveryID)
+		for _, meal := range delivery.Meals {
+			fmt.Printf("  %-20s %s\n", meal.MealName, meal.MenuMealName)
+		}
+	}
+	return nil
+}
+
+func diffCommand(ctx context.Context, d deps, opts options) error {
+	previous := storedSnapshot(d.state)
+	if previous == nil {
+		return fmt.Errorf("no snapshot stored in %s yet, run the changes command first", STATE_FILE)
+	}
+
+	snapshot, err := takeSnapshot(d)
+	if err != nil {
+		return err
+	}
+
+	changes := kuchniaviking.DiffSnapshots(*previous, snapshot)
+	if opts.output == outputJSON {
+		if changes == nil {
+			changes = []kuchniaviking.Change{}
+		}
+		return printJSON(changes)
+	}
+
+	if len(changes) == 0 {
+		fmt.Printf("no changes since %s\n", previous.TakenAt.Format(time.RFC3339))
+		return nil
+	}
+	for _, change := range changes {
+		fmt.Printf("%s  %s\n%s\n\n", change.Date, change.Type, describeChange(change))
+	}
+	return nil
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func setupLogging(opts options) {
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	if opts.verbose {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	}
+}
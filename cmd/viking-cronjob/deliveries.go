@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"git.jakub.app/jakub/X/internal/kuchniaviking"
+	"github.com/rs/zerolog/log"
+)
+
+// tomorrow returns the start of the next day, the way delivery dates are
+// compared.
+func tomorrow() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+}
+
+// fetchDeliveries returns the deliveries of the first active order that
+// match query, together with the client used to fetch their details.
+func fetchDeliveries(query kuchniaviking.DeliveryQuery) (kuchniaviking.KuchniaVikinga, []kuchniaviking.Delivery, error) {
+	kv, err := kuchniaviking.New()
+	if err != nil {
+		log.Error().Err(err).Msg("can't initialize kuchnia vikinga")
+		return nil, nil, err
+	}
+
+	ids, err := kv.GetActiveIds()
+	if err != nil {
+		log.Error().Err(err).Msg("can't get active ids")
+		return nil, nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, nil, errors.New("you don't have active order!")
+	}
+
+	orderDataResp, err := kv.GetOrderData(ids[0])
+	if err != nil {
+		log.Error().Err(err).Int("orderId", ids[0]).Msg("can't get orderData")
+		return nil, nil, err
+	}
+
+	deliveries, err := kv.GetDeliveries(orderDataResp.Deliveries, query)
+	if err != nil {
+		log.Error().Err(err).Time("from", query.From).Time("to", query.To).Int("limit", query.Limit).Msg("can't get deliveries")
+		return nil, nil, err
+	}
+	return kv, deliveries, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"git.jakub.app/jakub/X/internal/kuchniaviking"
+	"git.jakub.app/jakub/X/internal/notify"
+	"github.com/rs/zerolog/log"
+)
+
+const kindDigest = "digest"
+
+// sendDigest posts tomorrow's menu, or the next --days days, one alert per
+// delivery.
+func sendDigest(ctx context.Context, d deps, report *runReport) error {
+	from := tomorrow()
+	kv, deliveries, err := fetchDeliveries(kuchniaviking.DeliveryQuery{
+		From: from,
+		To:   from.AddDate(0, 0, d.horizon(1)-1),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		if delivery.Deleted {
+			continue
+		}
+
+		deliveryInfo, err := kv.GetDeliveryInfo(delivery.DeliveryID)
+		if err != nil {
+			log.Error().Err(err).Int("deliveryId", delivery.DeliveryID).Msg("can't get delivery info")
+			deliveryErrorsTotal.Inc()
+			report.add(delivery.DeliveryID, delivery.Date, deliveryFailed, err)
+			continue
+		}
+		deliveriesCheckedTotal.Inc()
+
+		if len(deliveryInfo.DeliveryMenuMeal) == 0 {
+			report.add(delivery.DeliveryID, delivery.Date, deliveryOK, nil)
+			continue
+		}
+
+		if err := d.notifier.Notify(ctx, digestAlert(delivery.Date, deliveryInfo.DeliveryMenuMeal)); err != nil {
+			log.Error().Err(err).Msg("failed to send menu digest")
+			alertFailuresTotal.WithLabelValues(kindDigest).Inc()
+			report.add(delivery.DeliveryID, delivery.Date, deliveryFailed, err)
+			continue
+		}
+		alertsSentTotal.WithLabelValues(kindDigest).Inc()
+		report.add(delivery.DeliveryID, delivery.Date, deliveryAlerted, nil)
+	}
+
+	return nil
+}
+
+// digestAlert lists every meal of a delivery in the order they are eaten,
+// with the daily totals in the description.
+func digestAlert(date string, meals []kuchniaviking.DeliveryMenuItem) notify.Alert {
+	meals = append([]kuchniaviking.DeliveryMenuItem(nil), meals...)
+	sort.SliceStable(meals, func(i, j int) bool {
+		return meals[i].MealPriority < meals[j].MealPriority
+	})
+
+	var total kuchniaviking.Nutrition
+	fields := make([]notify.Field, 0, len(meals))
+	for _, meal := range meals {
+		total.Calories += meal.Nutrition.Calories
+		total.Protein += meal.Nutrition.Protein
+		total.Fat += meal.Nutrition.Fat
+		total.Carbohydrate += meal.Nutrition.Carbohydrate
+
+		value := fmt.Sprintf("**%s**\n%s", meal.MenuMealName, meal.Nutrition.Macros())
+		if ingredients := majorIngredients(meal); len(ingredients) > 0 {
+			value += "\n" + strings.Join(ingredients, ", ")
+		}
+
+		fields = append(fields, notify.Field{
+			Name:  meal.MealName,
+			Value: value,
+		})
+	}
+
+	return notify.Alert{
+		Kind:        kindDigest,
+		Title:       fmt.Sprintf("🍽️ Menu for %s", date),
+		Description: total.Macros(),
+		Color:       0x2ECC71,
+		Fields:      fields,
+	}
+}
+
+func majorIngredients(meal kuchniaviking.DeliveryMenuItem) []string {
+	var names []string
+	for _, ingredient := range meal.Ingredients {
+		if ingredient.Major {
+			names = append(names, ingredient.Name)
+		}
+	}
+	return names
+}
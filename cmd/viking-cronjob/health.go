@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"git.jakub.app/jakub/X/internal/env"
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	HEALTH_ADDR = env.GetEnv("CRONJOB_HEALTH_ADDR", ":8081")
+)
+
+type healthServer struct {
+	server  *http.Server
+	state   *stateStore
+	cron    *cron.Cron
+	entries map[string]cron.EntryID
+	running atomic.Bool
+}
+
+func newHealthServer(addr string, st *stateStore, c *cron.Cron, entries map[string]cron.EntryID) *healthServer {
+	h := &healthServer{state: st, cron: c, entries: entries}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", h.handleHealth)
+	h.server = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return h
+}
+
+func (h *healthServer) setRunning(running bool) {
+	h.running.Store(running)
+}
+
+func (h *healthServer) serve() {
+	log.Info().Str("addr", h.server.Addr).Msg("starting health server")
+	if err := h.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Error().Err(err).Msg("health server failed")
+	}
+}
+
+func (h *healthServer) shutdown(ctx context.Context) error {
+	return h.server.Shutdown(ctx)
+}
+
+// handleHealth answers 200 while the scheduler runs and 503 otherwise. A
+// failed job does not make the daemon unhealthy, restarting would not help,
+// but its state is part of the response.
+func (h *healthServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	type jobHealth struct {
+		*jobState
+		NextRun *time.Time `json:"nextRun,omitempty"`
+	}
+
+	response := struct {
+		Status string               `json:"status"`
+		Jobs   map[string]jobHealth `json:"jobs"`
+	}{
+		Status: "ok",
+		Jobs:   make(map[string]jobHealth),
+	}
+
+	h.state.view(func(s *state) {
+		for name := range h.entries {
+			copied := jobState{}
+			if js, ok := s.Jobs[name]; ok {
+				copied = *js
+			}
+			response.Jobs[name] = jobHealth{jobState: &copied}
+		}
+	})
+
+	code := http.StatusOK
+	if !h.running.Load() {
+		code = http.StatusServiceUnavailable
+		response.Status = "stopped"
+	} else {
+		for name, id := range h.entries {
+			if next := h.cron.Entry(id).Next; !next.IsZero() {
+				jh := response.Jobs[name]
+				jh.NextRun = &next
+				response.Jobs[name] = jh
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
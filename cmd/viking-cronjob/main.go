@@ -1,135 +1,62 @@
 package main
 
 import (
-	"fmt"
-	"git.jakub.app/jakub/X/cmd/layla/modules/discord"
+	"context"
+	"errors"
+	"flag"
 	"git.jakub.app/jakub/X/internal/env"
-	"git.jakub.app/jakub/X/internal/kuchniaviking"
 	"github.com/rs/zerolog/log"
-	"strings"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 var (
 	DISCORD_WEBHOOK_URL = env.GetEnv("DISCORD_WEBHOOK", "")
+	// DAEMON makes daemon the default command instead of check-allergens.
+	DAEMON = env.GetEnvAsBool("CRONJOB_DAEMON", false)
 )
 
-type svc struct {
-	discordModule *discord.Discord
-}
-
-func run() error {
-	var err error
-	svc := svc{}
-	svc.discordModule, err = discord.New()
-	if err != nil {
-		log.Error().Err(err).Msg("can't load discord module")
-		return err
-	}
+// Exit codes, a partial run finished but some deliveries failed.
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+	exitPartial = 3
+)
 
-	return nil
+func main() {
+	os.Exit(run(os.Args[1:]))
 }
 
-func main() {
-	kv, err := kuchniaviking.New()
-	if err != nil {
-		log.Fatal().Err(err).Msg("can't initialize kuchnia vikinga")
+func run(args []string) int {
+	cmd, opts, err := parseArgs(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
 	}
-
-	ids, err := kv.GetActiveIds()
 	if err != nil {
-		log.Fatal().Err(err).Msg("can't get active ids")
+		log.Error().Err(err).Msg("invalid arguments")
+		return exitUsage
 	}
+	setupLogging(opts)
 
-	if len(ids) == 0 {
-		log.Fatal().Msg("you don't have active order!")
-	}
-
-	orderDataResp, err := kv.GetOrderData(ids[0])
+	d, err := newDeps(opts)
 	if err != nil {
-		log.Fatal().Err(err).Int("orderId", ids[0]).Msg("can't get orderData")
-	}
-	nearestDeliveries, err := kv.GetNearestDeliveries(orderDataResp.Deliveries, 3)
-
-	for _, nearestDelivery := range nearestDeliveries {
-		deliveryInfo, err := kv.GetDeliveryInfo(nearestDelivery.DeliveryID)
-		if err != nil {
-			log.Error().Err(err).Int("deliveryId", nearestDelivery.DeliveryID).Msg("can't get delivery info")
-			break
-		}
-
-		var allergyMeals []kuchniaviking.DeliveryMenuItem
-		allergens := map[string]bool{
-			"ryba":       true,
-			"skorupiaki": true,
-		}
-
-		for _, meal := range deliveryInfo.DeliveryMenuMeal {
-			fmt.Printf("- - - %s - - -\n", nearestDelivery.Date)
-			fmt.Printf("mealName: %s\n", meal.MealName)
-			fmt.Printf("menuMealName: %s\n", meal.MenuMealName)
-			fmt.Printf("ingredients: %v\n", meal.Ingredients)
-
-			for _, ingredient := range meal.Ingredients {
-				lowerName := strings.ToLower(ingredient.Name)
-				for allergen := range allergens {
-					if strings.Contains(lowerName, allergen) {
-						allergyMeals = append(allergyMeals, meal)
-						break
-					}
-				}
-			}
-		}
-
-		fmt.Printf("allergyMeals: %v\n", allergyMeals)
-		fmt.Printf("- - - - - - - - - - - - - - - - - -\n")
-
-		if len(allergyMeals) > 0 {
-			var fields []discord.EmbedField
-			for _, meal := range allergyMeals {
-				fields = append(fields, discord.EmbedField{
-					Name:   "Date",
-					Value:  nearestDelivery.Date,
-					Inline: false,
-				})
-
-				fields = append(fields, discord.EmbedField{
-					Name:   "Meal",
-					Value:  meal.MenuMealName,
-					Inline: false,
-				})
-
-				var allergenIngredients []string
-				for _, ingredient := range meal.Ingredients {
-					lowerName := strings.ToLower(ingredient.Name)
-					for allergen := range allergens {
-						if strings.Contains(lowerName, allergen) {
-							allergenIngredients = append(allergenIngredients, ingredient.Name)
-						}
-					}
-				}
-
-				fields = append(fields, discord.EmbedField{
-					Name:   "Allergen Ingredients",
-					Value:  strings.Join(allergenIngredients, "\n"),
-					Inline: false,
-				})
-			}
-
-			embed := discord.Embed{
-				Title:       "⚠️ Allergen Alert",
-				Description: fmt.Sprintf("Found %d meals containing allergens!", len(allergyMeals)),
-				Color:       0xFF0000,
-				Fields:      fields,
-			}
-
-			err := discord.SendMessageWithEmbed(DISCORD_WEBHOOK_URL, "", embed)
-			if err != nil {
-				log.Error().Err(err).Msg("failed to send Discord webhook")
-			}
-		}
+		log.Error().Err(err).Msg("can't start")
+		return exitFailure
 	}
 
-	if err := run(); err != nil {
-		panic(err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = cmd.run(ctx, d, opts)
+	switch {
+	case errors.Is(err, errPartialRun):
+		log.Warn().Str("command", cmd.name).Msg("command finished with failed deliveries")
+		return exitPartial
+	case err != nil:
+		log.Error().Err(err).Str("command", cmd.name).Msg("command failed")
+		return exitFailure
 	}
+	return exitOK
 }
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"git.jakub.app/jakub/X/internal/env"
+	"git.jakub.app/jakub/X/internal/kuchniaviking"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// METRICS_TEXTFILE is a path for the node_exporter textfile collector,
+	// usually ending in .prom.
+	METRICS_TEXTFILE = env.GetEnv("CRONJOB_METRICS_TEXTFILE", "")
+	PUSHGATEWAY_URL  = env.GetEnv("CRONJOB_PUSHGATEWAY_URL", "")
+)
+
+var (
+	registry = prometheus.NewRegistry()
+
+	deliveriesCheckedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "viking_cronjob",
+		Name:      "deliveries_checked_total",
+		Help:      "Deliveries whose menu was fetched by a job.",
+	})
+
+	deliveryErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "viking_cronjob",
+		Name:      "delivery_errors_total",
+		Help:      "Deliveries whose menu could not be fetched.",
+	})
+
+	alertsSentTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "viking_cronjob",
+		Name:      "alerts_sent_total",
+		Help:      "Alerts sent by kind, e.g. allergen, reminder or digest.",
+	}, []string{"kind"})
+
+	alertFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "viking_cronjob",
+		Name:      "alert_failures_total",
+		Help:      "Alerts that failed to send by kind.",
+	}, []string{"kind"})
+
+	lastRunTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "viking_cronjob",
+		Name:      "last_run_timestamp_seconds",
+		Help:      "Unix time of the last finished run by job.",
+	}, []string{"job"})
+
+	lastRunSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "viking_cronjob",
+		Name:      "last_run_success",
+		Help:      "Whether the last run of a job finished without errors (1) or not (0).",
+	}, []string{"job"})
+
+	lastRunFailedDeliveries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "viking_cronjob",
+		Name:      "last_run_failed_deliveries",
+		Help:      "Deliveries that failed in the last run of a job, non-zero for partial runs.",
+	}, []string{"job"})
+)
+
+func init() {
+	registry.MustRegister(
+		deliveriesCheckedTotal,
+		deliveryErrorsTotal,
+		alertsSentTotal,
+		alertFailuresTotal,
+		lastRunTimestamp,
+		lastRunSuccess,
+		lastRunFailedDeliveries,
+	)
+	if err := kuchniaviking.RegisterMetrics(registry); err != nil {
+		panic(err)
+	}
+}
+
+func recordRun(report *runReport) {
+	lastRunTimestamp.WithLabelValues(report.Job).Set(float64(report.FinishedAt.Unix()))
+	lastRunFailedDeliveries.WithLabelValues(report.Job).Set(float64(report.failedDeliveries()))
+	if report.Status == statusSuccess {
+		lastRunSuccess.WithLabelValues(report.Job).Set(1)
+	} else {
+		lastRunSuccess.WithLabelValues(report.Job).Set(0)
+	}
+}
+
+// exportMetrics writes the textfile and pushes to the Pushgateway when either
+// is configured. Failures are logged, they must not fail the run.
+func exportMetrics() {
+	if METRICS_TEXTFILE != "" {
+		if err := prometheus.WriteToTextfile(METRICS_TEXTFILE, registry); err != nil {
+			log.Error().Err(err).Str("path", METRICS_TEXTFILE).Msg("can't write metrics textfile")
+		}
+	}
+
+	if PUSHGATEWAY_URL != "" {
+		if err := push.New(PUSHGATEWAY_URL, "viking_cronjob").Gatherer(registry).Push(); err != nil {
+			log.Error().Err(err).Str("url", PUSHGATEWAY_URL).Msg("can't push metrics")
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"git.jakub.app/jakub/X/cmd/layla/modules/discord"
+	"git.jakub.app/jakub/X/internal/env"
+	"git.jakub.app/jakub/X/internal/notify"
+)
+
+const (
+	kindAllergen = "allergen"
+	kindReminder = "reminder"
+	kindChanges  = "changes"
+)
+
+var (
+	NOTIFY_DISCORD_DM_USER_ID = env.GetEnv("NOTIFY_DISCORD_DM_USER_ID", "")
+
+	NOTIFY_SMTP_ADDR     = env.GetEnv("NOTIFY_SMTP_ADDR", "")
+	NOTIFY_SMTP_USERNAME = env.GetEnv("NOTIFY_SMTP_USERNAME", "")
+	NOTIFY_SMTP_PASSWORD = env.GetEnv("NOTIFY_SMTP_PASSWORD", "")
+	NOTIFY_SMTP_FROM     = env.GetEnv("NOTIFY_SMTP_FROM", "")
+	NOTIFY_SMTP_TO       = env.GetEnvAsSlice("NOTIFY_SMTP_TO", nil, ",")
+
+	NOTIFY_PUSH_URL      = env.GetEnv("NOTIFY_PUSH_URL", "")
+	NOTIFY_PUSH_FORMAT   = env.GetEnv("NOTIFY_PUSH_FORMAT", "ntfy")
+	NOTIFY_PUSH_TOKEN    = env.GetEnv("NOTIFY_PUSH_TOKEN", "")
+	NOTIFY_PUSH_PRIORITY = env.GetEnvAsInt("NOTIFY_PUSH_PRIORITY", 0)
+
+	NOTIFY_TELEGRAM_TOKEN   = env.GetEnv("NOTIFY_TELEGRAM_TOKEN", "")
+	NOTIFY_TELEGRAM_CHAT_ID = env.GetEnv("NOTIFY_TELEGRAM_CHAT_ID", "")
+
+	NOTIFY_WEBHOOK_URL     = env.GetEnv("NOTIFY_WEBHOOK_URL", "")
+	NOTIFY_WEBHOOK_HEADERS = env.GetEnvAsSlice("NOTIFY_WEBHOOK_HEADERS", nil, ",")
+
+	// NOTIFY_ROUTES maps alert kinds to sinks, e.g.
+	// "allergen=discord,email;changes=discord". Defaults to every
+	// configured sink for every kind.
+	NOTIFY_ROUTES = env.GetEnv("NOTIFY_ROUTES", "")
+)
+
+// newNotifier registers a sink for every configured backend and routes alert
+// kinds to them according to NOTIFY_ROUTES.
+func newNotifier() (notify.Notifier, error) {
+	router := notify.NewRouter()
+	var sinks []string
+	add := func(name string, n notify.Notifier) {
+		router.AddSink(name, n)
+		sinks = append(sinks, name)
+	}
+
+	if DISCORD_WEBHOOK_URL != "" {
+		add("discord", &notify.DiscordWebhook{URL: DISCORD_WEBHOOK_URL})
+	}
+
+	if NOTIFY_DISCORD_DM_USER_ID != "" {
+		d, err := discord.New()
+		if err != nil {
+			return nil, err
+		}
+		add("discord-dm", &notify.DiscordDM{Session: d.Session(), UserID: NOTIFY_DISCORD_DM_USER_ID})
+	}
+
+	if NOTIFY_SMTP_ADDR != "" {
+		add("email", &notify.SMTP{
+			Addr:     NOTIFY_SMTP_ADDR,
+			Username: NOTIFY_SMTP_USERNAME,
+			Password: NOTIFY_SMTP_PASSWORD,
+			From:     NOTIFY_SMTP_FROM,
+			To:       NOTIFY_SMTP_TO,
+		})
+	}
+
+	if NOTIFY_PUSH_URL != "" {
+		add("push", &notify.Push{
+			Format:   notify.PushFormat(NOTIFY_PUSH_FORMAT),
+			URL:      NOTIFY_PUSH_URL,
+			Token:    NOTIFY_PUSH_TOKEN,
+			Priority: NOTIFY_PUSH_PRIORITY,
+		})
+	}
+
+	if NOTIFY_TELEGRAM_TOKEN != "" {
+		add("telegram", &notify.Telegram{Token: NOTIFY_TELEGRAM_TOKEN, ChatID: NOTIFY_TELEGRAM_CHAT_ID})
+	}
+
+	if NOTIFY_WEBHOOK_URL != "" {
+		headers := make(map[string]string)
+		for _, h := range NOTIFY_WEBHOOK_HEADERS {
+			name, value, ok := strings.Cut(h, ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid webhook header %q, expected Name:Value", h)
+			}
+			headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+		add("webhook", &notify.Webhook{URL: NOTIFY_WEBHOOK_URL, Headers: headers})
+	}
+
+	if NOTIFY_ROUTES == "" {
+		router.Route("*", sinks...)
+		return router, nil
+	}
+
+	if err := router.ParseRoutes(NOTIFY_ROUTES); err != nil {
+		return nil, err
+	}
+	return router, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+)
+
+// Run statuses. A partial run finished but failed for some deliveries.
+const (
+	statusSuccess = "success"
+	statusPartial = "partial"
+	statusFailure = "failure"
+)
+
+const (
+	deliveryOK      = "ok"
+	deliveryAlerted = "alerted"
+	deliveryFailed  = "failed"
+)
+
+var errPartialRun = errors.New("run finished with failed deliveries")
+
+type deliveryResult struct {
+	DeliveryID int    `json:"deliveryId"`
+	Date       string `json:"date"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+// runReport is the machine-readable outcome of one job run.
+type runReport struct {
+	Job        string           `json:"job"`
+	Status     string           `json:"status"`
+	DryRun     bool             `json:"dryRun,omitempty"`
+	StartedAt  time.Time        `json:"startedAt"`
+	FinishedAt time.Time        `json:"finishedAt"`
+	Error      string           `json:"error,omitempty"`
+	Deliveries []deliveryResult `json:"deliveries"`
+}
+
+func newRunReport(job string, dryRun bool) *runReport {
+	return &runReport{
+		Job:        job,
+		DryRun:     dryRun,
+		StartedAt:  time.Now(),
+		Deliveries: []deliveryResult{},
+	}
+}
+
+// add records the outcome for one delivery, a non-nil err marks it failed.
+func (r *runReport) add(deliveryID int, date string, status string, err error) {
+	result := deliveryResult{
+		DeliveryID: deliveryID,
+		Date:       date,
+		Status:     status,
+	}
+	if err != nil {
+		result.Status = deliveryFailed
+		result.Error = err.Error()
+	}
+	r.Deliveries = append(r.Deliveries, result)
+}
+
+func (r *runReport) failedDeliveries() int {
+	var n int
+	for _, result := range r.Deliveries {
+		if result.Status == deliveryFailed {
+			n++
+		}
+	}
+	return n
+}
+
+// finish sets the status from the job error and the delivery results.
+func (r *runReport) finish(err error) {
+	r.FinishedAt = time.Now()
+	switch {
+	case err != nil:
+		r.Status = statusFailure
+		r.Error = err.Error()
+	case r.failedDeliveries() > 0:
+		r.Status = statusPartial
+	default:
+		r.Status = statusSuccess
+	}
+}
+
+// err returns errPartialRun for partial runs so callers can tell them apart
+// from failures.
+func (r *runReport) err() error {
+	switch r.Status {
+	case statusFailure:
+		return errors.New(r.Error)
+	case statusPartial:
+		return errPartialRun
+	}
+	return nil
+}
+
+// write stores the report as JSON in path, "-" writes to stdout.
+func (r *runReport) write(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
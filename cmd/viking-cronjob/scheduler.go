@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"git.jakub.app/jakub/X/internal/env"
+	"git.jakub.app/jakub/X/internal/kuchniaviking"
+	"git.jakub.app/jakub/X/internal/notify"
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// Schedules use the standard 5-field cron syntax and may be prefixed with
+	// CRON_TZ=<zone>. An empty schedule disables the job.
+	SCHEDULE_ALLERGENS = env.GetEnv("CRONJOB_SCHEDULE_ALLERGENS", "0 18 * * *")
+	SCHEDULE_CHANGES   = env.GetEnv("CRONJOB_SCHEDULE_CHANGES", "*/30 * * * *")
+	SCHEDULE_REMINDER  = env.GetEnv("CRONJOB_SCHEDULE_REMINDER", "")
+	SCHEDULE_DIGEST    = env.GetEnv("CRONJOB_SCHEDULE_DIGEST", "0 19 * * *")
+)
+
+// deps is what every job needs to run.
+type deps struct {
+	state    *stateStore
+	notifier notify.Notifier
+	profile  kuchniaviking.AllergenProfile
+	// days overrides how many days ahead a job looks, zero keeps the job's
+	// default.
+	days   int
+	dryRun bool
+}
+
+func (d deps) horizon(def int) int {
+	if d.days > 0 {
+		return d.days
+	}
+	return def
+}
+
+type job struct {
+	name     string
+	schedule string
+	// run returns an error only when the whole run failed, per-delivery
+	// failures go to the report.
+	run func(ctx context.Context, d deps, report *runReport) error
+}
+
+func jobs() []job {
+	return []job{
+		{
+			name:     "allergens",
+			schedule: SCHEDULE_ALLERGENS,
+			run:      checkAllergens,
+		},
+		{
+			name:     "reminder",
+			schedule: SCHEDULE_REMINDER,
+			run:      remindAllergens,
+		},
+		{
+			name:     "digest",
+			schedule: SCHEDULE_DIGEST,
+			run:      sendDigest,
+		},
+		{
+			name:     "changes",
+			schedule: SCHEDULE_CHANGES,
+			run:      detectChanges,
+		},
+	}
+}
+
+// runJob runs j once, records the outcome in the state file and metrics and
+// returns the report. Dry runs touch neither.
+func runJob(ctx context.Context, d deps, j job) *runReport {
+	st := d.state
+	report := newRunReport(j.name, d.dryRun)
+	if err := st.update(func(s *state) {
+		st.job(s, j.name).LastStart = &report.StartedAt
+	}); err != nil {
+		log.Error().Err(err).Str("job", j.name).Msg("can't persist job state")
+	}
+
+	log.Info().Str("job", j.name).Msg("job started")
+	report.finish(j.run(ctx, d, report))
+
+	if err := st.update(func(s *state) {
+		js := st.job(s, j.name)
+		js.LastEnd = &report.FinishedAt
+		js.Runs++
+		js.LastStatus = report.Status
+		js.LastError = report.Error
+		js.LastReport = report
+		if report.Status != statusSuccess {
+			js.Failures++
+		}
+	}); err != nil {
+		log.Error().Err(err).Str("job", j.name).Msg("can't persist job state")
+	}
+
+	if !d.dryRun {
+		recordRun(report)
+		exportMetrics()
+	}
+
+	event := log.Info()
+	if report.Status != statusSuccess {
+		event = log.Error()
+	}
+	event.
+		Str("job", j.name).
+		Str("status", report.Status).
+		Str("error", report.Error).
+		Int("deliveries", len(report.Deliveries)).
+		Int("failedDeliveries", report.failedDeliveries()).
+		Dur("duration", report.FinishedAt.Sub(report.StartedAt)).
+		Msg("job finished")
+	return report
+}
+
+type cronLogger struct{}
+
+func (cronLogger) Info(msg string, keysAndValues ...interface{}) {
+	log.Debug().Fields(keysAndValues).Msg("cron: " + msg)
+}
+
+func (cronLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	log.Error().Err(err).Fields(keysAndValues).Msg("cron: " + msg)
+}
+
+// runDaemon runs every scheduled job until ctx is cancelled. A job that is
+// still running when its next tick fires is skipped rather than overlapped.
+func runDaemon(ctx context.Context, d deps) error {
+	st := d.state
+
+	c := cron.New(cron.WithChain(
+		cron.Recover(cronLogger{}),
+		cron.SkipIfStillRunning(cronLogger{}),
+	))
+
+	entries := make(map[string]cron.EntryID)
+	for _, j := range jobs() {
+		if j.schedule == "" {
+			log.Info().Str("job", j.name).Msg("job disabled")
+			continue
+		}
+
+		id, err := c.AddFunc(j.schedule, func() {
+			runJob(ctx, d, j)
+		})
+		if err != nil {
+			return fmt.Errorf("invalid schedule %q for job %s: %w", j.schedule, j.name, err)
+		}
+		entries[j.name] = id
+		log.Info().Str("job", j.name).Str("schedule", j.schedule).Msg("job scheduled")
+	}
+
+	health := newHealthServer(HEALTH_ADDR, st, c, entries)
+	go health.serve()
+
+	c.Start()
+	health.setRunning(true)
+	<-ctx.Done()
+
+	log.Info().Msg("stopping scheduler, waiting for running jobs")
+	health.setRunning(false)
+	<-c.Stop().Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return health.shutdown(shutdownCtx)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"git.jakub.app/jakub/X/internal/env"
+	"git.jakub.app/jakub/X/internal/kuchniaviking"
+)
+
+var (
+	STATE_FILE = env.GetEnv("CRONJOB_STATE_FILE", "viking-cronjob-state.json")
+)
+
+type jobState struct {
+	LastStart  *time.Time `json:"lastStart,omitempty"`
+	LastEnd    *time.Time `json:"lastEnd,omitempty"`
+	LastStatus string     `json:"lastStatus,omitempty"`
+	LastError  string     `json:"lastError,omitempty"`
+	Runs       int        `json:"runs"`
+	Failures   int        `json:"failures"`
+	LastReport *runReport `json:"lastReport,omitempty"`
+}
+
+type state struct {
+	Jobs     map[string]*jobState    `json:"jobs"`
+	Snapshot *kuchniaviking.Snapshot `json:"snapshot,omitempty"`
+	// NotifiedAlerts holds allergen matches already sent, keyed by alertKey.
+	NotifiedAlerts map[string]notifiedAlert `json:"notifiedAlerts"`
+	// RemindedDeliveries maps delivery IDs to their date once the evening
+	// reminder was sent.
+	RemindedDeliveries map[int]string `json:"remindedDeliveries"`
+}
+
+// stateStore persists state as JSON. Every mutation goes through update so
+// concurrently running jobs don't overwrite each other.
+type stateStore struct {
+	mu    sync.Mutex
+	path  string
+	state state
+	// readOnly keeps changes in memory only, used by --dry-run.
+	readOnly bool
+}
+
+func loadState(path string) (*stateStore, error) {
+	s := &stateStore{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if err == nil {
+		if err := json.Unmarshal(data, &s.state); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.state.Jobs == nil {
+		s.state.Jobs = make(map[string]*jobState)
+	}
+	if s.state.NotifiedAlerts == nil {
+		s.state.NotifiedAlerts = make(map[string]notifiedAlert)
+	}
+	if s.state.RemindedDeliveries == nil {
+		s.state.RemindedDeliveries = make(map[int]string)
+	}
+	return s, nil
+}
+
+func (s *stateStore) view(fn func(st *state)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.state)
+}
+
+func (s *stateStore) update(fn func(st *state)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fn(&s.state)
+	if s.readOnly {
+		return nil
+	}
+	return s.save()
+}
+
+// save writes to a temporary file first so a crash never leaves a truncated
+// state file behind.
+func (s *stateStore) save() error {
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *stateStore) job(st *state, name string) *jobState {
+	js, ok := st.Jobs[name]
+	if !ok {
+		js = &jobState{}
+		st.Jobs[name] = js
+	}
+	return js
+}
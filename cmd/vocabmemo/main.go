@@ -7,18 +7,17 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"strings"
 
 	"git.jakub.app/jakub/X/cmd/layla/modules/discord"
 	"git.jakub.app/jakub/X/internal/env"
 	"git.jakub.app/jakub/X/internal/llm"
+	"git.jakub.app/jakub/X/internal/vocab"
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog/log"
 )
 
 const (
-	PROMPT_FORMAT = `Translate to Polish and return JSON. For sentences (has spaces): {"en":"input","pl":"translation","type":"sentence"}. For single words (no spaces): {"en":"word","pl":"translation","type":"noun|verb|etc","example":"Usage example","phonetic":"IPA","use_frequency":0-1,"difficulty":"easy|medium|hard"}. Input: %INPUT%`
-	OPENAI_MODEL  = "gpt-3.5-turbo"
+	OPENAI_MODEL = "gpt-3.5-turbo"
 )
 
 var (
@@ -68,7 +67,7 @@ func main() {
 			return c.JSON(http.StatusBadRequest, "invalid request")
 		}
 
-		finalPrompt := strings.Replace(PROMPT_FORMAT, "%INPUT%", req.Input, -1)
+		finalPrompt := vocab.Prompt(req.Input)
 		completionRequest := llm.CompletionRequest{
 			Messages: []llm.Message{
 				{Role: "user", Content: finalPrompt},
@@ -0,0 +1,15 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store is a byte-oriented key/value cache with per-key expiration.
+type Store interface {
+	// Get returns the cached value and whether it was found.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Purge removes every key owned by the store.
+	Purge(ctx context.Context) error
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	valkey "github.com/redis/go-redis/v9"
+)
+
+type Valkey struct {
+	rdb    *valkey.Client
+	prefix string
+}
+
+// NewValkey returns a Store that namespaces every key with prefix, so Purge
+// only removes keys written through this store.
+func NewValkey(rdb *valkey.Client, prefix string) *Valkey {
+	return &Valkey{rdb: rdb, prefix: prefix}
+}
+
+func (v *Valkey) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	res, err := v.rdb.Get(ctx, v.prefix+key).Bytes()
+	if err == valkey.Nil {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return res, true, nil
+}
+
+func (v *Valkey) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return v.rdb.Set(ctx, v.prefix+key, value, ttl).Err()
+}
+
+func (v *Valkey) Purge(ctx context.Context) error {
+	var (
+		cursor uint64
+		keys   []string
+	)
+	for {
+		var (
+			k   []string
+			err error
+		)
+		k, cursor, err = v.rdb.Scan(ctx, cursor, v.prefix+"*", 0).Result()
+		if err != nil {
+			return err
+		}
+		keys = append(keys, k...)
+		if cursor == 0 {
+			break
+		}
+	}
+
+	if len(keys) > 0 {
+		if _, err := v.rdb.Del(ctx, keys...).Result(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
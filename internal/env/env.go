@@ -4,6 +4,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func GetEnv(key, fallback string) string {
@@ -49,3 +50,11 @@ func GetEnvAsInt(name string, defaultVal int) int {
 	}
 	return defaultVal
 }
+
+func GetEnvAsDuration(name string, defaultVal time.Duration) time.Duration {
+	valueStr := GetEnv(name, "")
+	if value, err := time.ParseDuration(valueStr); err == nil {
+		return value
+	}
+	return defaultVal
+}
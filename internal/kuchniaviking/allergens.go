@@ -0,0 +1,69 @@
+package kuchniaviking
+
+import (
+	"strings"
+)
+
+// AllergenProfile is a list of allergens matched against meal ingredient names.
+type AllergenProfile struct {
+	Allergens []string
+}
+
+type AllergenMatch struct {
+	Allergen   string `json:"allergen"`
+	Ingredient string `json:"ingredient"`
+}
+
+type AllergyMeal struct {
+	DeliveryMenuItem
+	Matches []AllergenMatch `json:"allergenMatches"`
+}
+
+func NewAllergenProfile(allergens []string) AllergenProfile {
+	var profile AllergenProfile
+	for _, allergen := range allergens {
+		allergen = strings.ToLower(strings.TrimSpace(allergen))
+		if allergen == "" {
+			continue
+		}
+		profile.Allergens = append(profile.Allergens, allergen)
+	}
+	return profile
+}
+
+func DefaultAllergenProfile() AllergenProfile {
+	return NewAllergenProfile(VIKING_ALLERGENS)
+}
+
+// Evaluate returns every (allergen, ingredient) pair where the ingredient name
+// contains the allergen. Each ingredient is reported at most once per allergen.
+func (p AllergenProfile) Evaluate(meal DeliveryMenuItem) []AllergenMatch {
+	var matches []AllergenMatch
+	for _, ingredient := range meal.Ingredients {
+		lowerName := strings.ToLower(ingredient.Name)
+		for _, allergen := range p.Allergens {
+			if strings.Contains(lowerName, allergen) {
+				matches = append(matches, AllergenMatch{
+					Allergen:   allergen,
+					Ingredient: ingredient.Name,
+				})
+			}
+		}
+	}
+	return matches
+}
+
+func (p AllergenProfile) FilterMeals(meals []DeliveryMenuItem) []AllergyMeal {
+	var allergyMeals []AllergyMeal
+	for _, meal := range meals {
+		matches := p.Evaluate(meal)
+		if len(matches) == 0 {
+			continue
+		}
+		allergyMeals = append(allergyMeals, AllergyMeal{
+			DeliveryMenuItem: meal,
+			Matches:          matches,
+		})
+	}
+	return allergyMeals
+}
@@ -0,0 +1,76 @@
+package kuchniaviking
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewAllergenProfile(t *testing.T) {
+	profile := NewAllergenProfile([]string{" Mleko", "", "ORZECHY ", "  "})
+	want := []string{"mleko", "orzechy"}
+	if !reflect.DeepEqual(profile.Allergens, want) {
+		t.Errorf("Allergens = %q, want %q", profile.Allergens, want)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	profile := NewAllergenProfile([]string{"mleko", "orzech", "seler"})
+	meal := func(ingredients ...string) DeliveryMenuItem {
+		var item DeliveryMenuItem
+		for _, name := range ingredients {
+			item.Ingredients = append(item.Ingredients, Ingredient{Name: name})
+		}
+		return item
+	}
+
+	tests := []struct {
+		name string
+		meal DeliveryMenuItem
+		want []AllergenMatch
+	}{
+		{"no ingredients", meal(), nil},
+		{"no match", meal("ryż", "kurczak"), nil},
+		{"exact", meal("mleko"), []AllergenMatch{{Allergen: "mleko", Ingredient: "mleko"}}},
+		{"case insensitive", meal("MLEKO 2%"), []AllergenMatch{{Allergen: "mleko", Ingredient: "MLEKO 2%"}}},
+		{"substring", meal("orzechy laskowe"), []AllergenMatch{{Allergen: "orzech", Ingredient: "orzechy laskowe"}}},
+		{"several allergens in one ingredient", meal("sos z mlekiem i mleko orzechowe"), []AllergenMatch{
+			{Allergen: "mleko", Ingredient: "sos z mlekiem i mleko orzechowe"},
+			{Allergen: "orzech", Ingredient: "sos z mlekiem i mleko orzechowe"},
+		}},
+		{"ingredient order", meal("seler naciowy", "ryż", "mleko"), []AllergenMatch{
+			{Allergen: "seler", Ingredient: "seler naciowy"},
+			{Allergen: "mleko", Ingredient: "mleko"},
+		}},
+		{"allergen repeated in one ingredient", meal("mleko i mleko"), []AllergenMatch{{Allergen: "mleko", Ingredient: "mleko i mleko"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := profile.Evaluate(tt.meal); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterMeals(t *testing.T) {
+	profile := NewAllergenProfile([]string{"mleko"})
+	meals := []DeliveryMenuItem{
+		{MenuMealID: 1, Ingredients: []Ingredient{{Name: "ryż"}}},
+		{MenuMealID: 2, Ingredients: []Ingredient{{Name: "mleko"}, {Name: "kakao"}}},
+		{MenuMealID: 3},
+	}
+
+	got := profile.FilterMeals(meals)
+	if len(got) != 1 || got[0].MenuMealID != 2 {
+		t.Fatalf("FilterMeals() = %+v, want only meal 2", got)
+	}
+	want := []AllergenMatch{{Allergen: "mleko", Ingredient: "mleko"}}
+	if !reflect.DeepEqual(got[0].Matches, want) {
+		t.Errorf("Matches = %+v, want %+v", got[0].Matches, want)
+	}
+
+	if got := NewAllergenProfile(nil).FilterMeals(meals); got != nil {
+		t.Errorf("FilterMeals() with an empty profile = %+v, want none", got)
+	}
+}
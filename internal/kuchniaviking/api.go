@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 type Delivery struct {
@@ -79,6 +80,11 @@ type Nutrition struct {
 	CaloriesText        string  `json:"caloriesText"`
 }
 
+// Macros summarizes the calories and macronutrients for display.
+func (n Nutrition) Macros() string {
+	return fmt.Sprintf("%.0f kcal · protein %.0f g · fat %.0f g · carbs %.0f g", n.Calories, n.Protein, n.Fat, n.Carbohydrate)
+}
+
 type Ingredient struct {
 	Name      string      `json:"name"`
 	Major     bool        `json:"major"`
@@ -92,6 +98,15 @@ type Exclusion struct {
 }
 
 func (t *authTransport) authLogin(baseUrl, login, password string) error {
+	loginsTotal.Inc()
+	err := t.doAuthLogin(baseUrl, login, password)
+	if err != nil {
+		loginFailuresTotal.Inc()
+	}
+	return err
+}
+
+func (t *authTransport) doAuthLogin(baseUrl, login, password string) error {
 
 	formData := url.Values{}
 	formData.Set("username", login)
@@ -106,11 +121,14 @@ func (t *authTransport) authLogin(baseUrl, login, password string) error {
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
+	start := time.Now()
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
+		observeUpstream(req.URL.Path, 0, start)
 		return fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
+	observeUpstream(req.URL.Path, resp.StatusCode, start)
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -121,7 +139,9 @@ func (t *authTransport) authLogin(baseUrl, login, password string) error {
 		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
 	}
 
+	t.mu.Lock()
 	t.cookies = resp.Cookies()
+	t.mu.Unlock()
 	return nil
 }
 
@@ -0,0 +1,120 @@
+package kuchniaviking
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"git.jakub.app/jakub/X/internal/cache"
+	"git.jakub.app/jakub/X/internal/env"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	CACHE_TTL_ACTIVE_IDS    = env.GetEnvAsDuration("VIKING_CACHE_TTL_ACTIVE_IDS", 5*time.Minute)
+	CACHE_TTL_ORDER_DATA    = env.GetEnvAsDuration("VIKING_CACHE_TTL_ORDER_DATA", 5*time.Minute)
+	CACHE_TTL_DELIVERY_INFO = env.GetEnvAsDuration("VIKING_CACHE_TTL_DELIVERY_INFO", time.Hour)
+)
+
+// CacheTTL holds the expiration used for each upstream endpoint. A zero TTL
+// disables caching for that endpoint.
+type CacheTTL struct {
+	ActiveIds    time.Duration
+	OrderData    time.Duration
+	DeliveryInfo time.Duration
+}
+
+func DefaultCacheTTL() CacheTTL {
+	return CacheTTL{
+		ActiveIds:    CACHE_TTL_ACTIVE_IDS,
+		OrderData:    CACHE_TTL_ORDER_DATA,
+		DeliveryInfo: CACHE_TTL_DELIVERY_INFO,
+	}
+}
+
+type cachedKuchniaViking struct {
+	KuchniaVikinga
+	store cache.Store
+	ttl   CacheTTL
+}
+
+// NewCached wraps kv so that upstream responses are served from store until
+// their TTL expires.
+func NewCached(kv KuchniaVikinga, store cache.Store, ttl CacheTTL) KuchniaVikinga {
+	return &cachedKuchniaViking{
+		KuchniaVikinga: kv,
+		store:          store,
+		ttl:            ttl,
+	}
+}
+
+func (c *cachedKuchniaViking) GetActiveIds() ([]int, error) {
+	var ids []int
+	err := c.cached("active-ids", "active-ids", c.ttl.ActiveIds, &ids, func() (any, error) {
+		return c.KuchniaVikinga.GetActiveIds()
+	})
+	return ids, err
+}
+
+func (c *cachedKuchniaViking) GetOrderData(orderId int) (*GetOrderDataResponse, error) {
+	var result GetOrderDataResponse
+	err := c.cached("order", fmt.Sprintf("order:%d", orderId), c.ttl.OrderData, &result, func() (any, error) {
+		return c.KuchniaVikinga.GetOrderData(orderId)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *cachedKuchniaViking) GetDeliveryInfo(deliveryId int) (*DeliveryMenuResponse, error) {
+	var result DeliveryMenuResponse
+	err := c.cached("delivery", fmt.Sprintf("delivery:%d", deliveryId), c.ttl.DeliveryInfo, &result, func() (any, error) {
+		return c.KuchniaVikinga.GetDeliveryInfo(deliveryId)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// cached decodes the value stored under key into dst, or calls fetch and
+// stores its result. Cache failures are logged and never fail the call.
+func (c *cachedKuchniaViking) cached(endpoint, key string, ttl time.Duration, dst any, fetch func() (any, error)) error {
+	ctx := context.Background()
+
+	if ttl > 0 {
+		data, ok, err := c.store.Get(ctx, key)
+		if err != nil {
+			log.Warn().Err(err).Str("key", key).Msg("can't read from cache")
+		}
+		if ok {
+			err := json.Unmarshal(data, dst)
+			if err == nil {
+				cacheRequestsTotal.WithLabelValues(endpoint, "hit").Inc()
+				return nil
+			}
+			log.Warn().Err(err).Str("key", key).Msg("can't decode cached value")
+		}
+		cacheRequestsTotal.WithLabelValues(endpoint, "miss").Inc()
+	}
+
+	value, err := fetch()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if ttl > 0 {
+		if err := c.store.Set(ctx, key, data, ttl); err != nil {
+			log.Warn().Err(err).Str("key", key).Msg("can't write to cache")
+		}
+	}
+
+	return json.Unmarshal(data, dst)
+}
@@ -0,0 +1,254 @@
+package kuchniaviking
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type ChangeType string
+
+const (
+	DeliveryAdded   ChangeType = "delivery.added"
+	DeliveryDeleted ChangeType = "delivery.deleted"
+	MenuChanged     ChangeType = "menu.changed"
+)
+
+// Snapshot is the state of upcoming deliveries and their menus at one point
+// in time, compared by DiffSnapshots to detect changes.
+type Snapshot struct {
+	TakenAt time.Time `json:"takenAt"`
+	// From and To bound the dates the snapshot covers, an empty bound is
+	// open. Deliveries outside the window are unknown, not missing.
+	From       string                   `json:"from,omitempty"`
+	To         string                   `json:"to,omitempty"`
+	Deliveries map[int]DeliverySnapshot `json:"deliveries"`
+}
+
+// HasWindow reports whether the snapshot records the dates it covers,
+// snapshots stored by older versions don't.
+func (s Snapshot) HasWindow() bool {
+	return s.From != "" || s.To != ""
+}
+
+// covers reports whether date is inside the window of the snapshot.
+func (s Snapshot) covers(date string) bool {
+	return (s.From == "" || date >= s.From) && (s.To == "" || date <= s.To)
+}
+
+type DeliverySnapshot struct {
+	DeliveryID int    `json:"deliveryId"`
+	Date       string `json:"date"`
+	Deleted    bool   `json:"deleted"`
+	// MenuKnown is false when the menu could not be fetched, such deliveries
+	// never produce menu changes.
+	MenuKnown bool           `json:"menuKnown"`
+	Meals     []MealSnapshot `json:"meals"`
+}
+
+type MealSnapshot struct {
+	DeliveryMealID int    `json:"deliveryMealId"`
+	MealName       string `json:"mealName"`
+	MenuMealID     int    `json:"menuMealId"`
+	MenuMealName   string `json:"menuMealName"`
+}
+
+type Change struct {
+	Type       ChangeType        `json:"type"`
+	DeliveryID int               `json:"deliveryId"`
+	Date       string            `json:"date"`
+	Delivery   *DeliverySnapshot `json:"delivery,omitempty"`
+	Meals      []MealChange      `json:"meals,omitempty"`
+}
+
+// MealChange describes one meal slot of a delivery. Before is nil for added
+// meals and After is nil for removed ones.
+type MealChange struct {
+	DeliveryMealID int           `json:"deliveryMealId"`
+	MealName       string        `json:"mealName"`
+	Before         *MealSnapshot `json:"before"`
+	After          *MealSnapshot `json:"after"`
+}
+
+func NewMealSnapshots(meals []DeliveryMenuItem) []MealSnapshot {
+	snapshots := make([]MealSnapshot, len(meals))
+	for i, meal := range meals {
+		snapshots[i] = MealSnapshot{
+			DeliveryMealID: meal.DeliveryMealID,
+			MealName:       meal.MealName,
+			MenuMealID:     meal.MenuMealID,
+			MenuMealName:   meal.MenuMealName,
+		}
+	}
+	return snapshots
+}
+
+// TakeSnapshot fetches the deliveries of the first active order matching
+// query together with their menus. A failed menu fetch is recorded as an
+// unknown menu instead of failing the snapshot.
+func TakeSnapshot(kv KuchniaVikinga, query DeliveryQuery) (Snapshot, error) {
+	snapshot := Snapshot{
+		TakenAt:    time.Now(),
+		Deliveries: make(map[int]DeliverySnapshot),
+	}
+
+	ids, err := kv.GetActiveIds()
+	if err != nil {
+		return snapshot, fmt.Errorf("can't get active ids: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return snapshot, nil
+	}
+
+	orderData, err := kv.GetOrderData(ids[0])
+	if err != nil {
+		return snapshot, fmt.Errorf("can't get order data: %w", err)
+	}
+
+	deliveries, err := kv.GetDeliveries(orderData.Deliveries, query)
+	if err != nil {
+		return snapshot, fmt.Errorf("can't get deliveries: %w", err)
+	}
+	snapshot.From, snapshot.To = snapshotWindow(query, deliveries, snapshot.TakenAt)
+
+	for _, delivery := range deliveries {
+		ds := DeliverySnapshot{
+			DeliveryID: delivery.DeliveryID,
+			Date:       delivery.Date,
+			Deleted:    delivery.Deleted,
+		}
+
+		if !delivery.Deleted {
+			menu, err := kv.GetDeliveryInfo(delivery.DeliveryID)
+			if err == nil {
+				ds.MenuKnown = true
+				ds.Meals = NewMealSnapshots(menu.DeliveryMenuMeal)
+			}
+		}
+
+		snapshot.Deliveries[delivery.DeliveryID] = ds
+	}
+
+	return snapshot, nil
+}
+
+// snapshotWindow returns the dates covered by a snapshot of query. A limited
+// query that hit its limit ends at the last delivery it returned.
+func snapshotWindow(query DeliveryQuery, deliveries []Delivery, now time.Time) (from, to string) {
+	switch {
+	case !query.From.IsZero():
+		from = query.From.Format("2006-01-02")
+	case !query.IncludePast:
+		from = now.Format("2006-01-02")
+	}
+
+	if !query.To.IsZero() {
+		to = query.To.Format("2006-01-02")
+	}
+	if query.Limit > 0 && len(deliveries) >= query.Limit {
+		if last := deliveries[len(deliveries)-1].Date; to == "" || last < to {
+			to = last
+		}
+	}
+	return from, to
+}
+
+// DiffSnapshots lists what changed between two snapshots, ordered by date.
+// Deliveries that disappear because their date has passed are not reported.
+// The windows of the snapshots may differ, e.g. slide by a day, deliveries
+// that only one of them covers are neither added nor deleted.
+func DiffSnapshots(previous, current Snapshot) []Change {
+	today := current.TakenAt.Format("2006-01-02")
+
+	var changes []Change
+	for id, cur := range current.Deliveries {
+		prev, existed := previous.Deliveries[id]
+		if !existed && !previous.covers(cur.Date) {
+			continue
+		}
+		switch {
+		case !cur.Deleted && (!existed || prev.Deleted):
+			changes = append(changes, deliveryChange(DeliveryAdded, cur))
+		case cur.Deleted && existed && !prev.Deleted:
+			changes = append(changes, deliveryChange(DeliveryDeleted, cur))
+		case !cur.Deleted && prev.MenuKnown && cur.MenuKnown:
+			if meals := diffMeals(prev.Meals, cur.Meals); len(meals) > 0 {
+				changes = append(changes, Change{
+					Type:       MenuChanged,
+					DeliveryID: id,
+					Date:       cur.Date,
+					Meals:      meals,
+				})
+			}
+		}
+	}
+
+	for id, prev := range previous.Deliveries {
+		if _, ok := current.Deliveries[id]; ok || prev.Deleted || prev.Date < today || !current.covers(prev.Date) {
+			continue
+		}
+		changes = append(changes, deliveryChange(DeliveryDeleted, prev))
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Date != changes[j].Date {
+			return changes[i].Date < changes[j].Date
+		}
+		if changes[i].DeliveryID != changes[j].DeliveryID {
+			return changes[i].DeliveryID < changes[j].DeliveryID
+		}
+		return changes[i].Type < changes[j].Type
+	})
+
+	return changes
+}
+
+func deliveryChange(t ChangeType, delivery DeliverySnapshot) Change {
+	return Change{
+		Type:       t,
+		DeliveryID: delivery.DeliveryID,
+		Date:       delivery.Date,
+		Delivery:   &delivery,
+	}
+}
+
+func diffMeals(previous, current []MealSnapshot) []MealChange {
+	prevByID := make(map[int]MealSnapshot, len(previous))
+	for _, meal := range previous {
+		prevByID[meal.DeliveryMealID] = meal
+	}
+
+	var changes []MealChange
+	seen := make(map[int]bool, len(current))
+	for _, meal := range current {
+		seen[meal.DeliveryMealID] = true
+		prev, ok := prevByID[meal.DeliveryMealID]
+		if ok && prev.MenuMealID == meal.MenuMealID {
+			continue
+		}
+
+		change := MealChange{
+			DeliveryMealID: meal.DeliveryMealID,
+			MealName:       meal.MealName,
+			After:          &meal,
+		}
+		if ok {
+			change.Before = &prev
+		}
+		changes = append(changes, change)
+	}
+
+	for _, meal := range previous {
+		if seen[meal.DeliveryMealID] {
+			continue
+		}
+		changes = append(changes, MealChange{
+			DeliveryMealID: meal.DeliveryMealID,
+			MealName:       meal.MealName,
+			Before:         &meal,
+		})
+	}
+
+	return changes
+}
@@ -0,0 +1,159 @@
+package kuchniaviking
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	takenAt := time.Date(2025, 3, 11, 8, 0, 0, 0, time.UTC)
+	meals := func(menuMealIDs ...int) []MealSnapshot {
+		var result []MealSnapshot
+		for i, id := range menuMealIDs {
+			result = append(result, MealSnapshot{DeliveryMealID: i + 1, MealName: "meal", MenuMealID: id})
+		}
+		return result
+	}
+	delivery := func(id int, date string, menuMealIDs ...int) DeliverySnapshot {
+		return DeliverySnapshot{DeliveryID: id, Date: date, MenuKnown: true, Meals: meals(menuMealIDs...)}
+	}
+	snapshot := func(from, to string, deliveries ...DeliverySnapshot) Snapshot {
+		s := Snapshot{TakenAt: takenAt, From: from, To: to, Deliveries: make(map[int]DeliverySnapshot)}
+		for _, d := range deliveries {
+			s.Deliveries[d.DeliveryID] = d
+		}
+		return s
+	}
+	type change struct {
+		Type       ChangeType
+		DeliveryID int
+	}
+
+	tests := []struct {
+		name     string
+		previous Snapshot
+		current  Snapshot
+		want     []change
+	}{
+		{
+			name:     "unchanged",
+			previous: snapshot("2025-03-11", "2025-03-14", delivery(1, "2025-03-12", 10)),
+			current:  snapshot("2025-03-11", "2025-03-14", delivery(1, "2025-03-12", 10)),
+		},
+		{
+			name:     "added inside the window",
+			previous: snapshot("2025-03-11", "2025-03-14"),
+			current:  snapshot("2025-03-11", "2025-03-14", delivery(1, "2025-03-13", 10)),
+			want:     []change{{DeliveryAdded, 1}},
+		},
+		{
+			name:     "window slid onto an existing delivery",
+			previous: snapshot("2025-03-10", "2025-03-13", delivery(1, "2025-03-12", 10)),
+			current:  snapshot("2025-03-11", "2025-03-14", delivery(1, "2025-03-12", 10), delivery(2, "2025-03-14", 20)),
+		},
+		{
+			name:     "window shrank",
+			previous: snapshot("2025-03-11", "2025-03-20", delivery(1, "2025-03-12", 10), delivery(2, "2025-03-19", 20)),
+			current:  snapshot("2025-03-11", "2025-03-14", delivery(1, "2025-03-12", 10)),
+		},
+		{
+			name:     "removed inside the window",
+			previous: snapshot("2025-03-11", "2025-03-14", delivery(1, "2025-03-12", 10)),
+			current:  snapshot("2025-03-11", "2025-03-14"),
+			want:     []change{{DeliveryDeleted, 1}},
+		},
+		{
+			name:     "marked deleted",
+			previous: snapshot("2025-03-11", "2025-03-14", delivery(1, "2025-03-12", 10)),
+			current:  snapshot("2025-03-11", "2025-03-14", DeliverySnapshot{DeliveryID: 1, Date: "2025-03-12", Deleted: true}),
+			want:     []change{{DeliveryDeleted, 1}},
+		},
+		{
+			name:     "past deliveries are not deleted",
+			previous: snapshot("2025-03-10", "2025-03-13", delivery(1, "2025-03-10", 10)),
+			current:  snapshot("", "", delivery(2, "2025-03-12", 20)),
+			want:     []change{{DeliveryAdded, 2}},
+		},
+		{
+			name:     "menu changed",
+			previous: snapshot("2025-03-11", "2025-03-14", delivery(1, "2025-03-12", 10, 11)),
+			current:  snapshot("2025-03-11", "2025-03-14", delivery(1, "2025-03-12", 10, 12)),
+			want:     []change{{MenuChanged, 1}},
+		},
+		{
+			name:     "unknown menu is not a change",
+			previous: snapshot("2025-03-11", "2025-03-14", delivery(1, "2025-03-12", 10)),
+			current:  snapshot("2025-03-11", "2025-03-14", DeliverySnapshot{DeliveryID: 1, Date: "2025-03-12"}),
+		},
+		{
+			name:     "ordered by date",
+			previous: snapshot("2025-03-11", "2025-03-14"),
+			current:  snapshot("2025-03-11", "2025-03-14", delivery(2, "2025-03-14", 10), delivery(1, "2025-03-12", 10)),
+			want:     []change{{DeliveryAdded, 1}, {DeliveryAdded, 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []change
+			for _, c := range DiffSnapshots(tt.previous, tt.current) {
+				got = append(got, change{c.Type, c.DeliveryID})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffSnapshots() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffMeals(t *testing.T) {
+	previous := []MealSnapshot{
+		{DeliveryMealID: 1, MenuMealID: 10},
+		{DeliveryMealID: 2, MenuMealID: 20},
+	}
+	current := []MealSnapshot{
+		{DeliveryMealID: 1, MenuMealID: 10},
+		{DeliveryMealID: 2, MenuMealID: 21},
+		{DeliveryMealID: 3, MenuMealID: 30},
+	}
+
+	changes := diffMeals(previous, current)
+	if len(changes) != 2 {
+		t.Fatalf("diffMeals() returned %d changes, want 2", len(changes))
+	}
+	if c := changes[0]; c.DeliveryMealID != 2 || c.Before.MenuMealID != 20 || c.After.MenuMealID != 21 {
+		t.Errorf("changes[0] = %+v, want meal 2 changed from 20 to 21", c)
+	}
+	if c := changes[1]; c.DeliveryMealID != 3 || c.Before != nil || c.After.MenuMealID != 30 {
+		t.Errorf("changes[1] = %+v, want meal 3 added", c)
+	}
+}
+
+func TestSnapshotWindow(t *testing.T) {
+	now := time.Date(2025, 3, 11, 8, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC) }
+	deliveries := []Delivery{{Date: "2025-03-12"}, {Date: "2025-03-13"}}
+
+	tests := []struct {
+		name     string
+		query    DeliveryQuery
+		from, to string
+	}{
+		{"range", DeliveryQuery{From: day(11), To: day(14)}, "2025-03-11", "2025-03-14"},
+		{"open end", DeliveryQuery{}, "2025-03-11", ""},
+		{"past included", DeliveryQuery{IncludePast: true}, "", ""},
+		{"limit reached", DeliveryQuery{From: day(11), Limit: 2}, "2025-03-11", "2025-03-13"},
+		{"limit not reached", DeliveryQuery{From: day(11), Limit: 3}, "2025-03-11", ""},
+		{"limit before to", DeliveryQuery{From: day(11), To: day(20), Limit: 2}, "2025-03-11", "2025-03-13"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := snapshotWindow(tt.query, deliveries, now)
+			if from != tt.from || to != tt.to {
+				t.Errorf("snapshotWindow() = %q, %q, want %q, %q", from, to, tt.from, tt.to)
+			}
+		})
+	}
+}
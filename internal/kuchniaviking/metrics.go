@@ -0,0 +1,75 @@
+package kuchniaviking
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	upstreamRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "kuchniaviking",
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Latency of requests sent to the Kuchnia Vikinga panel.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"path", "status"})
+
+	loginsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "kuchniaviking",
+		Name:      "logins_total",
+		Help:      "Login attempts against the Kuchnia Vikinga panel.",
+	})
+
+	loginFailuresTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "kuchniaviking",
+		Name:      "login_failures_total",
+		Help:      "Failed login attempts against the Kuchnia Vikinga panel.",
+	})
+
+	cacheRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "kuchniaviking",
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups by endpoint and result (hit or miss).",
+	}, []string{"endpoint", "result"})
+)
+
+// RegisterMetrics registers the client metrics with reg. Metrics are recorded
+// regardless, registering only makes them visible to a gatherer.
+func RegisterMetrics(reg prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{
+		upstreamRequestDuration,
+		loginsTotal,
+		loginFailuresTotal,
+		cacheRequestsTotal,
+	} {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func observeUpstream(path string, status int, start time.Time) {
+	label := "error"
+	if status > 0 {
+		label = strconv.Itoa(status)
+	}
+	upstreamRequestDuration.WithLabelValues(pathTemplate(path), label).Observe(time.Since(start).Seconds())
+}
+
+// pathTemplate replaces numeric path segments with {id} to keep the label
+// cardinality bounded.
+func pathTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment == "" {
+			continue
+		}
+		if _, err := strconv.Atoi(segment); err == nil {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
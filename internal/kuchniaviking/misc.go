@@ -49,3 +49,61 @@ func (kv *kuchniaViking) GetNearestDeliveries(deliveries []Delivery, limit int)
 
 	return result, nil
 }
+
+// DeliveryQuery selects deliveries by date. Zero From/To mean no bound and a
+// non-positive Limit means no limit.
+type DeliveryQuery struct {
+	From        time.Time
+	To          time.Time
+	Limit       int
+	IncludePast bool
+}
+
+func (kv *kuchniaViking) GetDeliveries(deliveries []Delivery, query DeliveryQuery) ([]Delivery, error) {
+	type deliveryWithDate struct {
+		delivery *Delivery
+		date     time.Time
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var matched []deliveryWithDate
+	for i, delivery := range deliveries {
+		deliveryTime, err := time.Parse("2006-01-02", delivery.Date)
+		if err != nil {
+			log.Error().Err(err).Msg("can't parse delivery date")
+			continue
+		}
+
+		if !query.IncludePast && deliveryTime.Before(today) {
+			continue
+		}
+		if !query.From.IsZero() && deliveryTime.Before(query.From) {
+			continue
+		}
+		if !query.To.IsZero() && deliveryTime.After(query.To) {
+			continue
+		}
+
+		matched = append(matched, deliveryWithDate{
+			delivery: &deliveries[i],
+			date:     deliveryTime,
+		})
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].date.Before(matched[j].date)
+	})
+
+	if query.Limit > 0 && len(matched) > query.Limit {
+		matched = matched[:query.Limit]
+	}
+
+	result := make([]Delivery, len(matched))
+	for i, m := range matched {
+		result[i] = *m.delivery
+	}
+
+	return result, nil
+}
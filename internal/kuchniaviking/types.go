@@ -1,15 +1,18 @@
 package kuchniaviking
 
 import (
+	"fmt"
 	"git.jakub.app/jakub/X/internal/env"
 	"net/http"
+	"sync"
 	"time"
 )
 
 var (
-	BASE_URL        = "https://panel.kuchniavikinga.pl"
-	VIKING_LOGIN    = env.GetEnv("VIKING_LOGIN", "")
-	VIKING_PASSWORD = env.GetEnv("VIKING_PASSWORD", "")
+	BASE_URL         = "https://panel.kuchniavikinga.pl"
+	VIKING_LOGIN     = env.GetEnv("VIKING_LOGIN", "")
+	VIKING_PASSWORD  = env.GetEnv("VIKING_PASSWORD", "")
+	VIKING_ALLERGENS = env.GetEnvAsSlice("VIKING_ALLERGENS", []string{"ryba", "skorupiaki"}, ",")
 )
 
 type KuchniaVikinga interface {
@@ -17,6 +20,7 @@ type KuchniaVikinga interface {
 	GetOrderData(orderId int) (*GetOrderDataResponse, error)
 	GetDeliveryInfo(deliveryId int) (*DeliveryMenuResponse, error)
 	GetNearestDeliveries(deliveries []Delivery, limit int) ([]Delivery, error)
+	GetDeliveries(deliveries []Delivery, query DeliveryQuery) ([]Delivery, error)
 }
 
 type kuchniaViking struct {
@@ -27,21 +31,63 @@ type kuchniaViking struct {
 }
 
 type authTransport struct {
+	mu         sync.RWMutex
 	cookies    []*http.Cookie
 	underlying http.RoundTripper
+	baseUrl    string
+	login      string
+	password   string
 }
 
 func (t *authTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := t.roundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// The session cookie expires on long-running clients, log in again and
+	// retry once. Only bodiless requests can be replayed safely.
+	if resp.StatusCode != http.StatusUnauthorized || (r.Body != nil && r.Body != http.NoBody) {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	if err := t.authLogin(t.baseUrl, t.login, t.password); err != nil {
+		return nil, fmt.Errorf("failed to refresh session: %w", err)
+	}
+	return t.roundTrip(r)
+}
+
+func (t *authTransport) roundTrip(r *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.underlying.RoundTrip(t.withCookies(r))
+	if err != nil {
+		observeUpstream(r.URL.Path, 0, start)
+		return nil, err
+	}
+	observeUpstream(r.URL.Path, resp.StatusCode, start)
+	return resp, nil
+}
+
+func (t *authTransport) withCookies(r *http.Request) *http.Request {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	r = r.Clone(r.Context())
 	for _, c := range t.cookies {
 		if c != nil {
 			r.AddCookie(c)
 		}
 	}
-	return t.underlying.RoundTrip(r)
+	return r
 }
 
 func New() (KuchniaVikinga, error) {
-	t := &authTransport{}
+	t := &authTransport{
+		baseUrl:  BASE_URL,
+		login:    VIKING_LOGIN,
+		password: VIKING_PASSWORD,
+	}
 	err := t.authLogin(BASE_URL, VIKING_LOGIN, VIKING_PASSWORD)
 	if err != nil {
 		return nil, err
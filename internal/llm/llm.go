@@ -11,20 +11,36 @@ import (
 	"time"
 )
 
+const (
+    RoleSystem    = "system"
+    RoleUser      = "user"
+    RoleAssistant = "assistant"
+    RoleTool      = "tool"
+)
+
 type Message struct {
     Role    string
     Content string
+    // ToolCalls are the calls requested by an assistant message.
+    ToolCalls []ToolCall
+    // ToolCallID links a tool message to the call it answers.
+    ToolCallID string
 }
 
 type CompletionRequest struct {
     Messages    []Message
     MaxTokens   int
     Temperature float32
+    Tools       []Tool
+    // ToolChoice is "auto" (default with tools), "none" or "required".
+    ToolChoice string
 }
 
 type CompletionResponse struct {
-    Content string
-    Usage   TokenUsage
+    Content      string
+    ToolCalls    []ToolCall
+    FinishReason string
+    Usage        TokenUsage
 }
 
 type TokenUsage struct {
@@ -35,6 +51,10 @@ type TokenUsage struct {
 
 type Provider interface {
     Complete(ctx context.Context, req CompletionRequest) (CompletionResponse, error)
+    // Stream sends the completion in chunks as it is generated. The channel
+    // is closed after the last chunk, an error ends it with a chunk whose
+    // Err is set. Callers read it to the end or cancel ctx.
+    Stream(ctx context.Context, req CompletionRequest) (<-chan Chunk, error)
 }
 
 type OpenAIProvider struct {
@@ -51,53 +71,113 @@ func NewOpenAIProvider(apiKey, modelName string) *OpenAIProvider {
     }
 }
 
-func (p *OpenAIProvider) Complete(ctx context.Context, req CompletionRequest) (CompletionResponse, error) {
-    type OpenAIMessage struct {
-        Role    string `json:"role"`
-        Content string `json:"content"`
-    }
+type openAIFunctionCall struct {
+    Name      string `json:"name"`
+    Arguments string `json:"arguments"`
+}
 
-    type OpenAIRequest struct {
-        Model       string         `json:"model"`
-        Messages    []OpenAIMessage `json:"messages"`
-        MaxTokens   int            `json:"max_tokens,omitempty"`
-        Temperature float32        `json:"temperature,omitempty"`
-    }
+type openAIToolCall struct {
+    ID       string             `json:"id"`
+    Type     string             `json:"type"`
+    Function openAIFunctionCall `json:"function"`
+}
 
-    type OpenAIChoice struct {
-        Message struct {
-            Content string `json:"content"`
-        } `json:"message"`
-        FinishReason string `json:"finish_reason"`
-    }
+type openAIMessage struct {
+    Role       string           `json:"role"`
+    Content    string           `json:"content"`
+    ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+    ToolCallID string           `json:"tool_call_id,omitempty"`
+}
 
-    type OpenAIResponse struct {
-        Choices []OpenAIChoice `json:"choices"`
-        Usage   struct {
-            PromptTokens     int `json:"prompt_tokens"`
-            CompletionTokens int `json:"completion_tokens"`
-            TotalTokens      int `json:"total_tokens"`
-        } `json:"usage"`
+type openAIFunction struct {
+    Name        string          `json:"name"`
+    Description string          `json:"description,omitempty"`
+    Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+type openAITool struct {
+    Type     string         `json:"type"`
+    Function openAIFunction `json:"function"`
+}
+
+type openAIStreamOptions struct {
+    IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIRequest struct {
+    Model         string               `json:"model"`
+    Messages      []openAIMessage      `json:"messages"`
+    MaxTokens     int                  `json:"max_tokens,omitempty"`
+    Temperature   float32              `json:"temperature,omitempty"`
+    Tools         []openAITool         `json:"tools,omitempty"`
+    ToolChoice    string               `json:"tool_choice,omitempty"`
+    Stream        bool                 `json:"stream,omitempty"`
+    StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIUsage struct {
+    PromptTokens     int `json:"prompt_tokens"`
+    CompletionTokens int `json:"completion_tokens"`
+    TotalTokens      int `json:"total_tokens"`
+}
+
+func (u openAIUsage) tokenUsage() TokenUsage {
+    return TokenUsage{
+        PromptTokens:     u.PromptTokens,
+        CompletionTokens: u.CompletionTokens,
+        TotalTokens:      u.TotalTokens,
     }
+}
 
-    messages := make([]OpenAIMessage, len(req.Messages))
+// newRequest builds the chat completions request, used both by Complete and
+// Stream.
+func (p *OpenAIProvider) newRequest(ctx context.Context, req CompletionRequest, stream bool) (*http.Request, error) {
+    messages := make([]openAIMessage, len(req.Messages))
     for i, msg := range req.Messages {
-        messages[i] = OpenAIMessage{
-            Role:    msg.Role,
-            Content: msg.Content,
+        messages[i] = openAIMessage{
+            Role:       msg.Role,
+            Content:    msg.Content,
+            ToolCallID: msg.ToolCallID,
+        }
+        for _, call := range msg.ToolCalls {
+            messages[i].ToolCalls = append(messages[i].ToolCalls, openAIToolCall{
+                ID:       call.ID,
+                Type:     "function",
+                Function: openAIFunctionCall{Name: call.Name, Arguments: call.Arguments},
+            })
         }
     }
 
-    openAIReq := OpenAIRequest{
+    var tools []openAITool
+    for _, tool := range req.Tools {
+        tools = append(tools, openAITool{
+            Type: "function",
+            Function: openAIFunction{
+                Name:        tool.Name,
+                Description: tool.Description,
+                Parameters:  tool.Parameters,
+            },
+        })
+    }
+
+    openAIReq := openAIRequest{
         Model:       p.modelName,
         Messages:    messages,
         MaxTokens:   req.MaxTokens,
         Temperature: req.Temperature,
+        Tools:       tools,
+    }
+    if len(tools) > 0 {
+        openAIReq.ToolChoice = req.ToolChoice
+    }
+    if stream {
+        openAIReq.Stream = true
+        openAIReq.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
     }
 
     jsonBody, err := json.Marshal(openAIReq)
     if err != nil {
-        return CompletionResponse{}, fmt.Errorf("marshaling request: %w", err)
+        return nil, fmt.Errorf("marshaling request: %w", err)
     }
 
     httpReq, err := http.NewRequestWithContext(
@@ -107,11 +187,32 @@ func (p *OpenAIProvider) Complete(ctx context.Context, req CompletionRequest) (C
         bytes.NewReader(jsonBody),
     )
     if err != nil {
-        return CompletionResponse{}, fmt.Errorf("creating request: %w", err)
+        return nil, fmt.Errorf("creating request: %w", err)
     }
 
     httpReq.Header.Set("Content-Type", "application/json")
     httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.apiKey))
+    return httpReq, nil
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, req CompletionRequest) (CompletionResponse, error) {
+    type OpenAIChoice struct {
+        Message struct {
+            Content   string           `json:"content"`
+            ToolCalls []openAIToolCall `json:"tool_calls"`
+        } `json:"message"`
+        FinishReason string `json:"finish_reason"`
+    }
+
+    type OpenAIResponse struct {
+        Choices []OpenAIChoice `json:"choices"`
+        Usage   openAIUsage    `json:"usage"`
+    }
+
+    httpReq, err := p.newRequest(ctx, req, false)
+    if err != nil {
+        return CompletionResponse{}, err
+    }
 
     client := &http.Client{Timeout: 30 * time.Second}
     resp, err := client.Do(httpReq)
@@ -138,13 +239,21 @@ func (p *OpenAIProvider) Complete(ctx context.Context, req CompletionRequest) (C
         return CompletionResponse{}, errors.New("no completions returned")
     }
 
+    choice := openAIResp.Choices[0]
+    var toolCalls []ToolCall
+    for _, call := range choice.Message.ToolCalls {
+        toolCalls = append(toolCalls, ToolCall{
+            ID:        call.ID,
+            Name:      call.Function.Name,
+            Arguments: call.Function.Arguments,
+        })
+    }
+
     return CompletionResponse{
-        Content: openAIResp.Choices[0].Message.Content,
-        Usage: TokenUsage{
-            PromptTokens:     openAIResp.Usage.PromptTokens,
-            CompletionTokens: openAIResp.Usage.CompletionTokens,
-            TotalTokens:      openAIResp.Usage.TotalTokens,
-        },
+        Content:      choice.Message.Content,
+        ToolCalls:    toolCalls,
+        FinishReason: choice.FinishReason,
+        Usage:        openAIResp.Usage.tokenUsage(),
     }, nil
 }
 
@@ -0,0 +1,192 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Chunk is a part of a streamed completion.
+type Chunk struct {
+	// Content is the text generated since the previous chunk.
+	Content string
+	// ToolCalls are set on the last chunk when the model calls tools, the
+	// streamed argument deltas already joined.
+	ToolCalls    []ToolCall
+	FinishReason string
+	// Usage is set on the last chunk when the provider reports it.
+	Usage *TokenUsage
+	Err   error
+}
+
+// streamClient has no timeout, a stream lasts as long as the generation and
+// is bounded by the context instead.
+var streamClient = &http.Client{}
+
+// maxSSELine bounds a single server-sent event line.
+const maxSSELine = 1 << 20
+
+func (p *OpenAIProvider) Stream(ctx context.Context, req CompletionRequest) (<-chan Chunk, error) {
+	httpReq, err := p.newRequest(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := streamClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("making request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	chunks := make(chan Chunk)
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+
+		send := func(c Chunk) bool {
+			select {
+			case chunks <- c:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		if err := readOpenAIStream(resp.Body, send); err != nil {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			send(Chunk{Err: err})
+		}
+	}()
+	return chunks, nil
+}
+
+// readOpenAIStream parses the server-sent events of a streamed chat
+// completion and passes every content delta to send. Tool call deltas are
+// collected and sent with the finish reason and usage in a last chunk.
+func readOpenAIStream(body io.Reader, send func(Chunk) bool) error {
+	type openAIToolCallDelta struct {
+		Index    int                `json:"index"`
+		ID       string             `json:"id"`
+		Function openAIFunctionCall `json:"function"`
+	}
+
+	type openAIStreamResponse struct {
+		Choices []struct {
+			Delta struct {
+				Content   string                `json:"content"`
+				ToolCalls []openAIToolCallDelta `json:"tool_calls"`
+			} `json:"delta"`
+			FinishReason *string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage *openAIUsage `json:"usage"`
+	}
+
+	var (
+		last      Chunk
+		toolCalls []ToolCall
+		done      bool
+	)
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxSSELine)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		// Empty lines end events and lines starting with a colon are
+		// comments, every payload fits on a single data line.
+		data, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok {
+			continue
+		}
+		data = bytes.TrimSpace(data)
+		if string(data) == "[DONE]" {
+			done = true
+			break
+		}
+
+		var event openAIStreamResponse
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("parsing stream event: %w", err)
+		}
+
+		if event.Usage != nil {
+			usage := event.Usage.tokenUsage()
+			last.Usage = &usage
+		}
+		if len(event.Choices) == 0 {
+			continue
+		}
+
+		choice := event.Choices[0]
+		for _, delta := range choice.Delta.ToolCalls {
+			for len(toolCalls) <= delta.Index {
+				toolCalls = append(toolCalls, ToolCall{})
+			}
+			call := &toolCalls[delta.Index]
+			if delta.ID != "" {
+				call.ID = delta.ID
+			}
+			call.Name += delta.Function.Name
+			call.Arguments += delta.Function.Arguments
+		}
+		if choice.FinishReason != nil {
+			last.FinishReason = *choice.FinishReason
+		}
+
+		if choice.Delta.Content != "" && !send(Chunk{Content: choice.Delta.Content}) {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading stream: %w", err)
+	}
+	if !done {
+		return fmt.Errorf("reading stream: %w", io.ErrUnexpectedEOF)
+	}
+
+	last.ToolCalls = toolCalls
+	send(last)
+	return nil
+}
+
+func (c *Client) Stream(ctx context.Context, req CompletionRequest) (<-chan Chunk, error) {
+	return c.provider.Stream(ctx, req)
+}
+
+// Collect reads a stream to its end and joins it into a response.
+func Collect(chunks <-chan Chunk) (CompletionResponse, error) {
+	var (
+		resp    CompletionResponse
+		content strings.Builder
+	)
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			resp.Content = content.String()
+			return resp, chunk.Err
+		}
+		content.WriteString(chunk.Content)
+		if chunk.ToolCalls != nil {
+			resp.ToolCalls = chunk.ToolCalls
+		}
+		if chunk.FinishReason != "" {
+			resp.FinishReason = chunk.FinishReason
+		}
+		if chunk.Usage != nil {
+			resp.Usage = *chunk.Usage
+		}
+	}
+	resp.Content = content.String()
+	return resp, nil
+}
@@ -0,0 +1,120 @@
+package llm
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadOpenAIStream(t *testing.T) {
+	usage := &TokenUsage{PromptTokens: 10, CompletionTokens: 3, TotalTokens: 13}
+
+	tests := []struct {
+		name    string
+		body    string
+		want    []Chunk
+		wantErr error
+	}{
+		{
+			name: "content",
+			body: `data: {"choices":[{"delta":{"content":"Hel"}}]}
+
+data: {"choices":[{"delta":{"content":"lo"},"finish_reason":"stop"}]}
+
+data: {"choices":[],"usage":{"prompt_tokens":10,"completion_tokens":3,"total_tokens":13}}
+
+data: [DONE]
+
+`,
+			want: []Chunk{{Content: "Hel"}, {Content: "lo"}, {FinishReason: "stop", Usage: usage}},
+		},
+		{
+			name: "comments and no space after data",
+			body: ": keep-alive\n\ndata:{\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\nevent: ignored\n\ndata:[DONE]\n",
+			want: []Chunk{{Content: "hi"}, {}},
+		},
+		{
+			name: "empty deltas",
+			body: `data: {"choices":[{"delta":{"role":"assistant","content":""}}]}
+data: {"choices":[{"delta":{},"finish_reason":"stop"}]}
+data: [DONE]
+`,
+			want: []Chunk{{FinishReason: "stop"}},
+		},
+		{
+			name: "tool calls",
+			body: `data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"get_menu","arguments":""}}]}}]}
+data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"date\":"}}]}}]}
+data: {"choices":[{"delta":{"tool_calls":[{"index":1,"id":"call_2","function":{"name":"translate_word","arguments":"{\"word\":\"cat\"}"}}]}}]}
+data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"today\"}"}}]},"finish_reason":"tool_calls"}]}
+data: [DONE]
+`,
+			want: []Chunk{{
+				ToolCalls: []ToolCall{
+					{ID: "call_1", Name: "get_menu", Arguments: `{"date":"today"}`},
+					{ID: "call_2", Name: "translate_word", Arguments: `{"word":"cat"}`},
+				},
+				FinishReason: "tool_calls",
+			}},
+		},
+		{
+			name:    "truncated",
+			body:    `data: {"choices":[{"delta":{"content":"Hel"}}]}` + "\n",
+			want:    []Chunk{{Content: "Hel"}},
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "empty body",
+			body:    "",
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "line too long",
+			body:    "data: " + strings.Repeat("x", maxSSELine) + "\n",
+			wantErr: bufio.ErrTooLong,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []Chunk
+			err := readOpenAIStream(strings.NewReader(tt.body), func(c Chunk) bool {
+				got = append(got, c)
+				return true
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("readOpenAIStream() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("chunks = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadOpenAIStreamInvalidEvent(t *testing.T) {
+	err := readOpenAIStream(strings.NewReader("data: {not json}\n"), func(Chunk) bool { return true })
+	if err == nil {
+		t.Fatal("readOpenAIStream() succeeded on an invalid event")
+	}
+}
+
+func TestReadOpenAIStreamStop(t *testing.T) {
+	body := `data: {"choices":[{"delta":{"content":"a"}}]}
+data: {"choices":[{"delta":{"content":"b"}}]}
+`
+	var got []Chunk
+	err := readOpenAIStream(strings.NewReader(body), func(c Chunk) bool {
+		got = append(got, c)
+		return false
+	})
+	if err != nil {
+		t.Fatalf("readOpenAIStream() error = %v after the reader stopped", err)
+	}
+	if len(got) != 1 || got[0].Content != "a" {
+		t.Errorf("chunks = %+v, want only the first", got)
+	}
+}
@@ -0,0 +1,174 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxToolRounds bounds how often CompleteWithTools lets the model
+// call tools before it has to answer.
+const DefaultMaxToolRounds = 5
+
+// Tool is a function the model may call. Parameters is a JSON schema of the
+// arguments object.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage
+}
+
+// ToolCall is a call requested by the model. Arguments is a JSON object
+// matching the tool's Parameters.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
+}
+
+type ToolExecutor interface {
+	Tools() []Tool
+	Execute(ctx context.Context, call ToolCall) (string, error)
+}
+
+// CompleteWithTools completes req with the executor's tools, runs the tool
+// calls the model asks for and feeds the results back until it answers. A
+// failing tool is reported to the model as the call result. The returned
+// messages are the assistant and tool messages added on the way, the final
+// answer not included.
+func (c *Client) CompleteWithTools(ctx context.Context, req CompletionRequest, executor ToolExecutor, maxRounds int) (CompletionResponse, []Message, error) {
+	if maxRounds <= 0 {
+		maxRounds = DefaultMaxToolRounds
+	}
+
+	req.Tools = executor.Tools()
+	req.Messages = append([]Message(nil), req.Messages...)
+
+	var (
+		added []Message
+		usage TokenUsage
+	)
+	for round := 0; ; round++ {
+		// Out of rounds, the model has to answer with what it has.
+		if round == maxRounds {
+			req.ToolChoice = "none"
+		}
+
+		resp, err := c.Complete(ctx, req)
+		if err != nil {
+			return resp, added, err
+		}
+		usage.PromptTokens += resp.Usage.PromptTokens
+		usage.CompletionTokens += resp.Usage.CompletionTokens
+		usage.TotalTokens += resp.Usage.TotalTokens
+
+		if len(resp.ToolCalls) == 0 || round == maxRounds {
+			resp.Usage = usage
+			return resp, added, nil
+		}
+
+		messages, err := runToolCalls(ctx, executor, resp.Content, resp.ToolCalls)
+		if err != nil {
+			return resp, added, err
+		}
+		req.Messages = append(req.Messages, messages...)
+		added = append(added, messages...)
+	}
+}
+
+// StreamWithTools is CompleteWithTools streaming the answer. Content
+// generated while calling tools is streamed too, the last chunk carries the
+// usage of every round.
+func (c *Client) StreamWithTools(ctx context.Context, req CompletionRequest, executor ToolExecutor, maxRounds int) <-chan Chunk {
+	if maxRounds <= 0 {
+		maxRounds = DefaultMaxToolRounds
+	}
+
+	req.Tools = executor.Tools()
+	req.Messages = append([]Message(nil), req.Messages...)
+
+	chunks := make(chan Chunk)
+	go func() {
+		defer close(chunks)
+
+		send := func(c Chunk) bool {
+			select {
+			case chunks <- c:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var usage TokenUsage
+		for round := 0; ; round++ {
+			if round == maxRounds {
+				req.ToolChoice = "none"
+			}
+
+			stream, err := c.Stream(ctx, req)
+			if err != nil {
+				send(Chunk{Err: err})
+				return
+			}
+
+			var (
+				content strings.Builder
+				last    Chunk
+			)
+			for chunk := range stream {
+				if chunk.Err != nil {
+					send(chunk)
+					return
+				}
+				if chunk.Content != "" {
+					content.WriteString(chunk.Content)
+					if !send(Chunk{Content: chunk.Content}) {
+						return
+					}
+				}
+				if chunk.ToolCalls != nil || chunk.FinishReason != "" || chunk.Usage != nil {
+					last = chunk
+				}
+			}
+			if last.Usage != nil {
+				usage.PromptTokens += last.Usage.PromptTokens
+				usage.CompletionTokens += last.Usage.CompletionTokens
+				usage.TotalTokens += last.Usage.TotalTokens
+			}
+
+			if len(last.ToolCalls) == 0 || round == maxRounds {
+				last.Content = ""
+				last.Usage = &usage
+				send(last)
+				return
+			}
+
+			messages, err := runToolCalls(ctx, executor, content.String(), last.ToolCalls)
+			if err != nil {
+				send(Chunk{Err: err})
+				return
+			}
+			req.Messages = append(req.Messages, messages...)
+		}
+	}()
+	return chunks
+}
+
+// runToolCalls executes the calls of an assistant message and returns it
+// followed by the tool results.
+func runToolCalls(ctx context.Context, executor ToolExecutor, content string, calls []ToolCall) ([]Message, error) {
+	messages := []Message{{Role: RoleAssistant, Content: content, ToolCalls: calls}}
+	for _, call := range calls {
+		result, err := executor.Execute(ctx, call)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			result = fmt.Sprintf("error: %s", err)
+		}
+		messages = append(messages, Message{Role: RoleTool, Content: result, ToolCallID: call.ID})
+	}
+	return messages, nil
+}
@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"git.jakub.app/jakub/X/cmd/layla/modules/discord"
+	"github.com/bwmarrin/discordgo"
+)
+
+// DiscordWebhook posts alerts as embeds to a webhook. It keeps one client so
+// the rate limit state carries over between alerts.
+type DiscordWebhook struct {
+	URL string
+
+	once   sync.Once
+	client *discord.WebhookClient
+}
+
+func (d *DiscordWebhook) Notify(ctx context.Context, alert Alert) error {
+	d.once.Do(func() {
+		d.client = discord.NewWebhookClient(d.URL)
+	})
+	_, err := d.client.SendEmbeds(ctx, discord.WebhookMessage{}, []discord.Embed{embed(alert)}, discord.SendOptions{})
+	return err
+}
+
+func embed(alert Alert) discord.Embed {
+	fields := make([]discord.EmbedField, len(alert.Fields))
+	for i, f := range alert.Fields {
+		fields[i] = discord.EmbedField{Name: f.Name, Value: f.Value, Inline: f.Inline}
+	}
+
+	return discord.Embed{
+		Title:       alert.Title,
+		Description: alert.Description,
+		Color:       alert.Color,
+		Fields:      fields,
+		Timestamp:   &alert.Timestamp,
+	}
+}
+
+// DiscordDM sends alerts as direct messages from the bot to UserID.
+type DiscordDM struct {
+	Session *discordgo.Session
+	UserID  string
+}
+
+func (d *DiscordDM) Notify(ctx context.Context, alert Alert) error {
+	channel, err := d.Session.UserChannelCreate(d.UserID, discordgo.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("can't open DM channel: %w", err)
+	}
+
+	for _, group := range discord.GroupEmbeds(discord.SplitEmbed(embed(alert))) {
+		embeds := make([]*discordgo.MessageEmbed, len(group))
+		for i, e := range group {
+			fields := make([]*discordgo.MessageEmbedField, len(e.Fields))
+			for j, f := range e.Fields {
+				fields[j] = &discordgo.MessageEmbedField{Name: f.Name, Value: f.Value, Inline: f.Inline}
+			}
+			embeds[i] = &discordgo.MessageEmbed{
+				Title:       e.Title,
+				Description: e.Description,
+				Color:       e.Color,
+				Fields:      fields,
+				Timestamp:   alert.Timestamp.Format(time.RFC3339),
+			}
+		}
+
+		if _, err := d.Session.ChannelMessageSendEmbeds(channel.ID, embeds, discordgo.WithContext(ctx)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
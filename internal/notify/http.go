@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// post sends body to endpoint. Errors never contain the endpoint beyond its
+// host, as sinks like Telegram carry their token in the URL.
+func post(ctx context.Context, endpoint string, contentType string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", redactURL(err))
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request to %s: %w", req.URL.Host, redactURL(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// redactURL drops the URL a *url.Error wraps the cause with.
+func redactURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
+
+type PushFormat string
+
+const (
+	PushNtfy   PushFormat = "ntfy"
+	PushGotify PushFormat = "gotify"
+)
+
+// Push sends alerts to ntfy topics or Gotify servers. URL is the topic URL
+// for ntfy and the server base URL for Gotify, Token is optional for ntfy.
+type Push struct {
+	Format   PushFormat
+	URL      string
+	Token    string
+	Priority int
+}
+
+func (p *Push) Notify(ctx context.Context, alert Alert) error {
+	switch p.Format {
+	case PushGotify:
+		body, err := json.Marshal(map[string]any{
+			"title":    alert.Title,
+			"message":  alert.Text(),
+			"priority": p.Priority,
+		})
+		if err != nil {
+			return err
+		}
+		endpoint := strings.TrimSuffix(p.URL, "/") + "/message"
+		return post(ctx, endpoint, "application/json", body, map[string]string{"X-Gotify-Key": p.Token})
+	case PushNtfy, "":
+		headers := map[string]string{"Title": alert.Title}
+		if p.Priority > 0 {
+			headers["Priority"] = fmt.Sprint(p.Priority)
+		}
+		if p.Token != "" {
+			headers["Authorization"] = "Bearer " + p.Token
+		}
+		return post(ctx, p.URL, "text/plain; charset=utf-8", []byte(alert.Text()), headers)
+	}
+	return fmt.Errorf("unknown push format %q", p.Format)
+}
+
+type Telegram struct {
+	Token  string
+	ChatID string
+	// BaseURL overrides the Bot API endpoint, defaults to https://api.telegram.org.
+	BaseURL string
+}
+
+func (t *Telegram) Notify(ctx context.Context, alert Alert) error {
+	baseURL := t.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.telegram.org"
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"chat_id": t.ChatID,
+		"text":    alert.Title + "\n\n" + alert.Text(),
+	})
+	if err != nil {
+		return err
+	}
+	return post(ctx, fmt.Sprintf("%s/bot%s/sendMessage", baseURL, t.Token), "application/json", body, nil)
+}
+
+// Webhook posts the alert as JSON to URL with optional extra headers.
+type Webhook struct {
+	URL     string
+	Headers map[string]string
+}
+
+func (w *Webhook) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	return post(ctx, w.URL, "application/json", body, w.Headers)
+}
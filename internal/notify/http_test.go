@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPostRedactsURL(t *testing.T) {
+	const secret = "123456:secret-token"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	baseURL := srv.URL
+	// A closed server makes the transport fail.
+	srv.Close()
+
+	sinks := map[string]Notifier{
+		"telegram": &Telegram{Token: secret, ChatID: "1", BaseURL: baseURL},
+		"gotify":   &Push{Format: PushGotify, URL: baseURL, Token: secret},
+		"webhook":  &Webhook{URL: baseURL + "/hook/" + secret},
+	}
+	for name, sink := range sinks {
+		err := sink.Notify(context.Background(), Alert{Title: "test"})
+		if err == nil {
+			t.Errorf("%s: Notify() succeeded against a closed server", name)
+			continue
+		}
+		if strings.Contains(err.Error(), secret) {
+			t.Errorf("%s: error contains the token: %v", name, err)
+		}
+	}
+}
+
+func TestGotifyTokenHeader(t *testing.T) {
+	var gotKey, gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("X-Gotify-Key")
+		gotQuery = r.URL.RawQuery
+	}))
+	defer srv.Close()
+
+	p := &Push{Format: PushGotify, URL: srv.URL + "/", Token: "app-token"}
+	if err := p.Notify(context.Background(), Alert{Title: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if gotKey != "app-token" {
+		t.Errorf("X-Gotify-Key = %q, want %q", gotKey, "app-token")
+	}
+	if gotQuery != "" {
+		t.Errorf("query = %q, want none", gotQuery)
+	}
+}
@@ -0,0 +1,165 @@
+// Package notify delivers alerts to pluggable sinks such as Discord, e-mail
+// or push services.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Alert is a sink independent notification. Kind is used for routing, e.g.
+// "allergen" or "changes".
+type Alert struct {
+	Kind        string    `json:"kind"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Color       int       `json:"color,omitempty"`
+	Fields      []Field   `json:"fields,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	// Sinks limits a Router to these of the routed sinks, e.g. to retry the
+	// ones that failed.
+	Sinks []string `json:"-"`
+}
+
+type Field struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// Text renders the alert for sinks that only support plain text.
+func (a Alert) Text() string {
+	var b strings.Builder
+	if a.Description != "" {
+		b.WriteString(a.Description)
+		b.WriteString("\n")
+	}
+	for _, f := range a.Fields {
+		b.WriteString("\n")
+		b.WriteString(f.Name)
+		b.WriteString(":\n")
+		b.WriteString(f.Value)
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Router sends every alert to the sinks configured for its kind. Kinds
+// without a route use the "*" route, if any.
+type Router struct {
+	sinks  map[string]Notifier
+	routes map[string][]string
+}
+
+func NewRouter() *Router {
+	return &Router{
+		sinks:  make(map[string]Notifier),
+		routes: make(map[string][]string),
+	}
+}
+
+func (r *Router) AddSink(name string, n Notifier) {
+	r.sinks[name] = n
+}
+
+func (r *Router) Route(kind string, sinks ...string) {
+	r.routes[kind] = sinks
+}
+
+// ParseRoutes reads routes in the form "allergen=discord,email;*=discord".
+func (r *Router) ParseRoutes(spec string) error {
+	for _, route := range strings.Split(spec, ";") {
+		route = strings.TrimSpace(route)
+		if route == "" {
+			continue
+		}
+
+		kind, sinks, ok := strings.Cut(route, "=")
+		if !ok || strings.TrimSpace(kind) == "" {
+			return fmt.Errorf("notify: invalid route %q, expected kind=sink[,sink]", route)
+		}
+
+		var names []string
+		for _, name := range strings.Split(sinks, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if _, ok := r.sinks[name]; !ok {
+				return fmt.Errorf("notify: route %q uses unknown sink %q", kind, name)
+			}
+			names = append(names, name)
+		}
+		r.Route(strings.TrimSpace(kind), names...)
+	}
+	return nil
+}
+
+// ErrNoRoute is returned by Router.Notify when no sink is routed for an
+// alert, including when alert.Sinks matches none of the routed ones.
+var ErrNoRoute = errors.New("notify: no sink routed")
+
+// DeliveryError is returned by Router.Notify when some of the sinks failed.
+// The alert still reached the Delivered ones.
+type DeliveryError struct {
+	Delivered []string
+	Failed    []string
+	errs      []error
+}
+
+func (e *DeliveryError) Error() string {
+	if len(e.errs) == 0 {
+		return "notify: failed sinks: " + strings.Join(e.Failed, ", ")
+	}
+	return errors.Join(e.errs...).Error()
+}
+
+func (e *DeliveryError) Unwrap() []error {
+	return e.errs
+}
+
+// Notify sends the alert to every sink routed for its kind, or only to those
+// listed in alert.Sinks. When a sink fails the error is a *DeliveryError.
+func (r *Router) Notify(ctx context.Context, alert Alert) error {
+	names, ok := r.routes[alert.Kind]
+	if !ok {
+		names = r.routes["*"]
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("%w for %q alerts", ErrNoRoute, alert.Kind)
+	}
+	if alert.Sinks != nil {
+		names = slices.DeleteFunc(slices.Clone(names), func(name string) bool {
+			return !slices.Contains(alert.Sinks, name)
+		})
+		if len(names) == 0 {
+			return fmt.Errorf("%w for %q alerts among %s", ErrNoRoute, alert.Kind, strings.Join(alert.Sinks, ", "))
+		}
+	}
+
+	if alert.Timestamp.IsZero() {
+		alert.Timestamp = time.Now()
+	}
+
+	result := &DeliveryError{}
+	for _, name := range names {
+		if err := r.sinks[name].Notify(ctx, alert); err != nil {
+			result.Failed = append(result.Failed, name)
+			result.errs = append(result.errs, fmt.Errorf("notify: %s: %w", name, err))
+			continue
+		}
+		result.Delivered = append(result.Delivered, name)
+	}
+	if len(result.Failed) > 0 {
+		return result
+	}
+	return nil
+}
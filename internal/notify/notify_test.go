@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"testing"
+	"time"
+)
+
+type fakeSink struct {
+	err   error
+	calls int
+}
+
+func (f *fakeSink) Notify(ctx context.Context, alert Alert) error {
+	f.calls++
+	return f.err
+}
+
+func TestRouterPartialDelivery(t *testing.T) {
+	ok := &fakeSink{}
+	failing := &fakeSink{err: errors.New("boom")}
+
+	r := NewRouter()
+	r.AddSink("ok", ok)
+	r.AddSink("failing", failing)
+	r.Route("*", "ok", "failing")
+
+	err := r.Notify(context.Background(), Alert{Kind: "test"})
+	var deliveryErr *DeliveryError
+	if !errors.As(err, &deliveryErr) {
+		t.Fatalf("Notify() error = %v, want a *DeliveryError", err)
+	}
+	if !slices.Equal(deliveryErr.Delivered, []string{"ok"}) || !slices.Equal(deliveryErr.Failed, []string{"failing"}) {
+		t.Errorf("delivered %v, failed %v", deliveryErr.Delivered, deliveryErr.Failed)
+	}
+
+	// Retrying only the failed sink leaves the other one alone.
+	failing.err = nil
+	if err := r.Notify(context.Background(), Alert{Kind: "test", Sinks: deliveryErr.Failed}); err != nil {
+		t.Fatal(err)
+	}
+	if ok.calls != 1 || failing.calls != 2 {
+		t.Errorf("calls: ok %d, failing %d; want 1 and 2", ok.calls, failing.calls)
+	}
+}
+
+func TestRouterNoRoute(t *testing.T) {
+	sink := &fakeSink{}
+
+	r := NewRouter()
+	r.AddSink("discord", sink)
+	r.AddSink("email", sink)
+	r.Route("allergen", "discord")
+
+	tests := []struct {
+		name  string
+		alert Alert
+	}{
+		{"unrouted kind", Alert{Kind: "digest"}},
+		{"unrouted sink", Alert{Kind: "allergen", Sinks: []string{"email"}}},
+		{"unknown sink", Alert{Kind: "allergen", Sinks: []string{"discrod"}}},
+		{"empty sinks", Alert{Kind: "allergen", Sinks: []string{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := r.Notify(context.Background(), tt.alert); !errors.Is(err, ErrNoRoute) {
+				t.Errorf("Notify() error = %v, want %v", err, ErrNoRoute)
+			}
+		})
+	}
+	if sink.calls != 0 {
+		t.Errorf("sink called %d times, want 0", sink.calls)
+	}
+}
+
+func TestSMTPCancel(t *testing.T) {
+	// The server accepts the connection but never greets.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Read(make([]byte, 1))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	s := &SMTP{Addr: ln.Addr().String(), From: "from@example.com", To: []string{"to@example.com"}}
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Notify(ctx, Alert{Title: "test"})
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Notify() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Notify() kept running after ctx was done")
+	}
+}
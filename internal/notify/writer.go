@@ -0,0 +1,30 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Writer renders alerts to W instead of sending them, as text or as one JSON
+// object per line.
+type Writer struct {
+	W    io.Writer
+	JSON bool
+
+	mu sync.Mutex
+}
+
+func (w *Writer) Notify(ctx context.Context, alert Alert) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.JSON {
+		return json.NewEncoder(w.W).Encode(alert)
+	}
+
+	_, err := fmt.Fprintf(w.W, "[%s] %s\n%s\n\n", alert.Kind, alert.Title, alert.Text())
+	return err
+}
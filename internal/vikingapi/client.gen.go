@@ -0,0 +1,1649 @@
+// Package vikingapi provides primitives to interact with the openapi HTTP API.
+//
+// Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.4.1 DO NOT EDIT.
+package vikingapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/oapi-codegen/runtime"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+const (
+	ApiTokenScopes   = "apiToken.Scopes"
+	BasicAuthScopes  = "basicAuth.Scopes"
+	BearerAuthScopes = "bearerAuth.Scopes"
+)
+
+// Defines values for ChangeType.
+const (
+	DeliveryAdded   ChangeType = "delivery.added"
+	DeliveryDeleted ChangeType = "delivery.deleted"
+	MenuChanged     ChangeType = "menu.changed"
+)
+
+// AllergenMatch defines model for AllergenMatch.
+type AllergenMatch struct {
+	Allergen   string `json:"allergen"`
+	Ingredient string `json:"ingredient"`
+}
+
+// AllergyMeal defines model for AllergyMeal.
+type AllergyMeal struct {
+	AddedByUser           *bool           `json:"addedByUser,omitempty"`
+	AllergenMatches       []AllergenMatch `json:"allergenMatches"`
+	Allergens             *[]string       `json:"allergens"`
+	AllergensWithExcluded *[]interface{}  `json:"allergensWithExcluded"`
+	Amount                *int            `json:"amount,omitempty"`
+	DeliveryMealId        int             `json:"deliveryMealId"`
+	DeliveryMealSeen      *string         `json:"deliveryMealSeen,omitempty"`
+	DietCaloriesId        *int            `json:"dietCaloriesId,omitempty"`
+	DietCaloriesMealId    *int            `json:"dietCaloriesMealId,omitempty"`
+	Ingredients           *[]Ingredient   `json:"ingredients"`
+	MealAddingSource      *bool           `json:"mealAddingSource,omitempty"`
+	MealName              string          `json:"mealName"`
+	MealPriority          *int            `json:"mealPriority,omitempty"`
+	MenuMealId            int             `json:"menuMealId"`
+	MenuMealName          string          `json:"menuMealName"`
+	Nutrition             Nutrition       `json:"nutrition"`
+	Review                *interface{}    `json:"review"`
+	ReviewSummary         *interface{}    `json:"reviewSummary"`
+	Switchable            *bool           `json:"switchable,omitempty"`
+	Thermo                *string         `json:"thermo,omitempty"`
+}
+
+// Change defines model for Change.
+type Change struct {
+	Date       openapi_types.Date `json:"date"`
+	Delivery   *DeliverySnapshot  `json:"delivery,omitempty"`
+	DeliveryId int                `json:"deliveryId"`
+	Meals      *[]MealChange      `json:"meals,omitempty"`
+	Type       ChangeType         `json:"type"`
+}
+
+// ChangeType defines model for Change.Type.
+type ChangeType string
+
+// DeliveriesEnvelope defines model for DeliveriesEnvelope.
+type DeliveriesEnvelope struct {
+	Data       *[]DeliveryResponse `json:"data"`
+	Error      *EnvelopeError      `json:"error"`
+	Pagination *Pagination         `json:"pagination,omitempty"`
+	RequestId  string              `json:"requestId"`
+	Warnings   []Warning           `json:"warnings"`
+}
+
+// DeliveriesResponse defines model for DeliveriesResponse.
+type DeliveriesResponse struct {
+	Data    *[]DeliveryResponse `json:"data,omitempty"`
+	Error   *string             `json:"error,omitempty"`
+	Success bool                `json:"success"`
+}
+
+// DeliveryMenuItem defines model for DeliveryMenuItem.
+type DeliveryMenuItem struct {
+	AddedByUser           *bool          `json:"addedByUser,omitempty"`
+	Allergens             *[]string      `json:"allergens"`
+	AllergensWithExcluded *[]interface{} `json:"allergensWithExcluded"`
+	Amount                *int           `json:"amount,omitempty"`
+	DeliveryMealId        int            `json:"deliveryMealId"`
+	DeliveryMealSeen      *string        `json:"deliveryMealSeen,omitempty"`
+	DietCaloriesId        *int           `json:"dietCaloriesId,omitempty"`
+	DietCaloriesMealId    *int           `json:"dietCaloriesMealId,omitempty"`
+	Ingredients           *[]Ingredient  `json:"ingredients"`
+	MealAddingSource      *bool          `json:"mealAddingSource,omitempty"`
+	MealName              string         `json:"mealName"`
+	MealPriority          *int           `json:"mealPriority,omitempty"`
+	MenuMealId            int            `json:"menuMealId"`
+	MenuMealName          string         `json:"menuMealName"`
+	Nutrition             Nutrition      `json:"nutrition"`
+	Review                *interface{}   `json:"review"`
+	ReviewSummary         *interface{}   `json:"reviewSummary"`
+	Switchable            *bool          `json:"switchable,omitempty"`
+	Thermo                *string        `json:"thermo,omitempty"`
+}
+
+// DeliveryResponse defines model for DeliveryResponse.
+type DeliveryResponse struct {
+	AllergyMeals *[]AllergyMeal      `json:"allergyMeals"`
+	Date         openapi_types.Date  `json:"date"`
+	DeliveryId   int                 `json:"deliveryId"`
+	Meals        *[]DeliveryMenuItem `json:"meals"`
+}
+
+// DeliverySnapshot defines model for DeliverySnapshot.
+type DeliverySnapshot struct {
+	Date       openapi_types.Date `json:"date"`
+	Deleted    bool               `json:"deleted"`
+	DeliveryId int                `json:"deliveryId"`
+	Meals      *[]MealSnapshot    `json:"meals"`
+	MenuKnown  bool               `json:"menuKnown"`
+}
+
+// EnvelopeError defines model for EnvelopeError.
+type EnvelopeError struct {
+	// Code Machine readable error code, e.g. invalid_parameter, no_active_orders, upstream_error, upstream_unavailable, unauthorized, forbidden.
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorEnvelope defines model for ErrorEnvelope.
+type ErrorEnvelope struct {
+	Data       *interface{}   `json:"data"`
+	Error      *EnvelopeError `json:"error"`
+	Pagination *Pagination    `json:"pagination,omitempty"`
+	RequestId  string         `json:"requestId"`
+	Warnings   []Warning      `json:"warnings"`
+}
+
+// ErrorResponse defines model for ErrorResponse.
+type ErrorResponse struct {
+	Error   *string `json:"error,omitempty"`
+	Success bool    `json:"success"`
+}
+
+// Exclusion defines model for Exclusion.
+type Exclusion struct {
+	Chosen             bool   `json:"chosen"`
+	DietaryExclusionId int    `json:"dietaryExclusionId"`
+	Name               string `json:"name"`
+}
+
+// Ingredient defines model for Ingredient.
+type Ingredient struct {
+	Exclusion *[]Exclusion `json:"exclusion"`
+	Major     bool         `json:"major"`
+	Name      string       `json:"name"`
+}
+
+// MealChange defines model for MealChange.
+type MealChange struct {
+	After          *MealSnapshot `json:"after"`
+	Before         *MealSnapshot `json:"before"`
+	DeliveryMealId int           `json:"deliveryMealId"`
+	MealName       string        `json:"mealName"`
+}
+
+// MealSnapshot defines model for MealSnapshot.
+type MealSnapshot struct {
+	DeliveryMealId int    `json:"deliveryMealId"`
+	MealName       string `json:"mealName"`
+	MenuMealId     int    `json:"menuMealId"`
+	MenuMealName   string `json:"menuMealName"`
+}
+
+// Nutrition defines model for Nutrition.
+type Nutrition struct {
+	Calories            *float32 `json:"calories,omitempty"`
+	CaloriesText        *string  `json:"caloriesText,omitempty"`
+	Carbohydrate        *float32 `json:"carbohydrate,omitempty"`
+	DietaryFiber        *float32 `json:"dietaryFiber,omitempty"`
+	Fat                 *float32 `json:"fat,omitempty"`
+	Protein             *float32 `json:"protein,omitempty"`
+	Salt                *float32 `json:"salt,omitempty"`
+	SaturatedFattyAcids *float32 `json:"saturatedFattyAcids,omitempty"`
+	Sugar               *float32 `json:"sugar,omitempty"`
+	Weight              *float32 `json:"weight,omitempty"`
+}
+
+// Pagination defines model for Pagination.
+type Pagination struct {
+	Limit int `json:"limit"`
+
+	// NextOffset Offset of the next page, null on the last page.
+	NextOffset *int `json:"nextOffset"`
+	Offset     int  `json:"offset"`
+
+	// Total Deliveries matching the date filters, before paging.
+	Total int `json:"total"`
+}
+
+// PurgeEnvelope defines model for PurgeEnvelope.
+type PurgeEnvelope struct {
+	Data *struct {
+		Purged bool `json:"purged"`
+	} `json:"data"`
+	Error      *EnvelopeError `json:"error"`
+	Pagination *Pagination    `json:"pagination,omitempty"`
+	RequestId  string         `json:"requestId"`
+	Warnings   []Warning      `json:"warnings"`
+}
+
+// PurgeResponse defines model for PurgeResponse.
+type PurgeResponse struct {
+	Data *struct {
+		Purged bool `json:"purged"`
+	} `json:"data,omitempty"`
+	Error   *string `json:"error,omitempty"`
+	Success bool    `json:"success"`
+}
+
+// Warning defines model for Warning.
+type Warning struct {
+	Code       string `json:"code"`
+	DeliveryId *int   `json:"deliveryId,omitempty"`
+	Message    string `json:"message"`
+}
+
+// From defines model for from.
+type From = openapi_types.Date
+
+// IncludePast defines model for includePast.
+type IncludePast = bool
+
+// Limit defines model for limit.
+type Limit = int
+
+// Meal defines model for meal.
+type Meal = string
+
+// Offset defines model for offset.
+type Offset = int
+
+// To defines model for to.
+type To = openapi_types.Date
+
+// Error defines model for Error.
+type Error = ErrorResponse
+
+// GetDeliveriesParams defines parameters for GetDeliveries.
+type GetDeliveriesParams struct {
+	// From First delivery date to include.
+	From *From `form:"from,omitempty" json:"from,omitempty"`
+
+	// To Last delivery date to include.
+	To *To `form:"to,omitempty" json:"to,omitempty"`
+
+	// Limit Maximum number of deliveries.
+	Limit *Limit `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Offset Number of matching deliveries to skip.
+	Offset *Offset `form:"offset,omitempty" json:"offset,omitempty"`
+
+	// Meal Comma separated meal types matched against mealName, case insensitive. Deliveries without a matching meal are left out before offset and limit apply.
+	Meal *Meal `form:"meal,omitempty" json:"meal,omitempty"`
+
+	// IncludePast Include deliveries dated before today.
+	IncludePast *IncludePast `form:"includePast,omitempty" json:"includePast,omitempty"`
+}
+
+// GetDeliveriesHTMLParams defines parameters for GetDeliveriesHTML.
+type GetDeliveriesHTMLParams struct {
+	// From First delivery date to include.
+	From *From `form:"from,omitempty" json:"from,omitempty"`
+
+	// To Last delivery date to include.
+	To *To `form:"to,omitempty" json:"to,omitempty"`
+
+	// Limit Maximum number of deliveries.
+	Limit *Limit `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Offset Number of matching deliveries to skip.
+	Offset *Offset `form:"offset,omitempty" json:"offset,omitempty"`
+
+	// Meal Comma separated meal types matched against mealName, case insensitive. Deliveries without a matching meal are left out before offset and limit apply.
+	Meal *Meal `form:"meal,omitempty" json:"meal,omitempty"`
+
+	// IncludePast Include deliveries dated before today.
+	IncludePast *IncludePast `form:"includePast,omitempty" json:"includePast,omitempty"`
+}
+
+// StreamEventsParams defines parameters for StreamEvents.
+type StreamEventsParams struct {
+	// LastEventID Id of the last event received.
+	LastEventID *int `json:"Last-Event-ID,omitempty"`
+}
+
+// GetDeliveriesV1Params defines parameters for GetDeliveriesV1.
+type GetDeliveriesV1Params struct {
+	// From First delivery date to include.
+	From *From `form:"from,omitempty" json:"from,omitempty"`
+
+	// To Last delivery date to include.
+	To *To `form:"to,omitempty" json:"to,omitempty"`
+
+	// Limit Maximum number of deliveries.
+	Limit *Limit `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Offset Number of matching deliveries to skip.
+	Offset *Offset `form:"offset,omitempty" json:"offset,omitempty"`
+
+	// Meal Comma separated meal types matched against mealName, case insensitive. Deliveries without a matching meal are left out before offset and limit apply.
+	Meal *Meal `form:"meal,omitempty" json:"meal,omitempty"`
+
+	// IncludePast Include deliveries dated before today.
+	IncludePast *IncludePast `form:"includePast,omitempty" json:"includePast,omitempty"`
+}
+
+// RequestEditorFn  is the function signature for the RequestEditor callback function
+type RequestEditorFn func(ctx context.Context, req *http.Request) error
+
+// Doer performs HTTP requests.
+//
+// The standard http.Client implements this interface.
+type HttpRequestDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client which conforms to the OpenAPI3 specification for this service.
+type Client struct {
+	// The endpoint of the server conforming to this interface, with scheme,
+	// https://api.deepmap.com for example. This can contain a path relative
+	// to the server, such as https://api.deepmap.com/dev-test, and all the
+	// paths in the swagger spec will be appended to the server.
+	Server string
+
+	// Doer for performing requests, typically a *http.Client with any
+	// customized settings, such as certificate chains.
+	Client HttpRequestDoer
+
+	// A list of callbacks for modifying requests which are generated before sending over
+	// the network.
+	RequestEditors []RequestEditorFn
+}
+
+// ClientOption allows setting custom parameters during construction
+type ClientOption func(*Client) error
+
+// Creates a new Client, with reasonable defaults
+func NewClient(server string, opts ...ClientOption) (*Client, error) {
+	// create a client with sane default values
+	client := Client{
+		Server: server,
+	}
+	// mutate client and add all optional params
+	for _, o := range opts {
+		if err := o(&client); err != nil {
+			return nil, err
+		}
+	}
+	// ensure the server URL always has a trailing slash
+	if !strings.HasSuffix(client.Server, "/") {
+		client.Server += "/"
+	}
+	// create httpClient, if not already present
+	if client.Client == nil {
+		client.Client = &http.Client{}
+	}
+	return &client, nil
+}
+
+// WithHTTPClient allows overriding the default Doer, which is
+// automatically created using http.Client. This is useful for tests.
+func WithHTTPClient(doer HttpRequestDoer) ClientOption {
+	return func(c *Client) error {
+		c.Client = doer
+		return nil
+	}
+}
+
+// WithRequestEditorFn allows setting up a callback function, which will be
+// called right before sending the request. This can be used to mutate the request.
+func WithRequestEditorFn(fn RequestEditorFn) ClientOption {
+	return func(c *Client) error {
+		c.RequestEditors = append(c.RequestEditors, fn)
+		return nil
+	}
+}
+
+// The interface specification for the client above.
+type ClientInterface interface {
+	// PurgeCache request
+	PurgeCache(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetDeliveries request
+	GetDeliveries(ctx context.Context, params *GetDeliveriesParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetDeliveriesHTML request
+	GetDeliveriesHTML(ctx context.Context, params *GetDeliveriesHTMLParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// StreamEvents request
+	StreamEvents(ctx context.Context, params *StreamEventsParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// PurgeCacheV1 request
+	PurgeCacheV1(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetDeliveriesV1 request
+	GetDeliveriesV1(ctx context.Context, params *GetDeliveriesV1Params, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetMetrics request
+	GetMetrics(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetOpenAPI request
+	GetOpenAPI(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
+}
+
+func (c *Client) PurgeCache(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPurgeCacheRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetDeliveries(ctx context.Context, params *GetDeliveriesParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetDeliveriesRequest(c.Server, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetDeliveriesHTML(ctx context.Context, params *GetDeliveriesHTMLParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetDeliveriesHTMLRequest(c.Server, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) StreamEvents(ctx context.Context, params *StreamEventsParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewStreamEventsRequest(c.Server, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PurgeCacheV1(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPurgeCacheV1Request(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetDeliveriesV1(ctx context.Context, params *GetDeliveriesV1Params, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetDeliveriesV1Request(c.Server, params)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetMetrics(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetMetricsRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetOpenAPI(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetOpenAPIRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+// NewPurgeCacheRequest generates requests for PurgeCache
+func NewPurgeCacheRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/cache/purge")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetDeliveriesRequest generates requests for GetDeliveries
+func NewGetDeliveriesRequest(server string, params *GetDeliveriesParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/deliveries")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.From != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "from", runtime.ParamLocationQuery, *params.From); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.To != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "to", runtime.ParamLocationQuery, *params.To); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Limit != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "limit", runtime.ParamLocationQuery, *params.Limit); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Offset != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "offset", runtime.ParamLocationQuery, *params.Offset); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Meal != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "meal", runtime.ParamLocationQuery, *params.Meal); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.IncludePast != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "includePast", runtime.ParamLocationQuery, *params.IncludePast); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetDeliveriesHTMLRequest generates requests for GetDeliveriesHTML
+func NewGetDeliveriesHTMLRequest(server string, params *GetDeliveriesHTMLParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/deliveries/html")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.From != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "from", runtime.ParamLocationQuery, *params.From); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.To != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "to", runtime.ParamLocationQuery, *params.To); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Limit != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "limit", runtime.ParamLocationQuery, *params.Limit); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Offset != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "offset", runtime.ParamLocationQuery, *params.Offset); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Meal != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "meal", runtime.ParamLocationQuery, *params.Meal); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.IncludePast != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "includePast", runtime.ParamLocationQuery, *params.IncludePast); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewStreamEventsRequest generates requests for StreamEvents
+func NewStreamEventsRequest(server string, params *StreamEventsParams) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/events")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+
+		if params.LastEventID != nil {
+			var headerParam0 string
+
+			headerParam0, err = runtime.StyleParamWithLocation("simple", false, "Last-Event-ID", runtime.ParamLocationHeader, *params.LastEventID)
+			if err != nil {
+				return nil, err
+			}
+
+			req.Header.Set("Last-Event-ID", headerParam0)
+		}
+
+	}
+
+	return req, nil
+}
+
+// NewPurgeCacheV1Request generates requests for PurgeCacheV1
+func NewPurgeCacheV1Request(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/cache/purge")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetDeliveriesV1Request generates requests for GetDeliveriesV1
+func NewGetDeliveriesV1Request(server string, params *GetDeliveriesV1Params) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/v1/deliveries")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if params != nil {
+		queryValues := queryURL.Query()
+
+		if params.From != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "from", runtime.ParamLocationQuery, *params.From); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.To != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "to", runtime.ParamLocationQuery, *params.To); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Limit != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "limit", runtime.ParamLocationQuery, *params.Limit); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Offset != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "offset", runtime.ParamLocationQuery, *params.Offset); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.Meal != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "meal", runtime.ParamLocationQuery, *params.Meal); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		if params.IncludePast != nil {
+
+			if queryFrag, err := runtime.StyleParamWithLocation("form", true, "includePast", runtime.ParamLocationQuery, *params.IncludePast); err != nil {
+				return nil, err
+			} else if parsed, err := url.ParseQuery(queryFrag); err != nil {
+				return nil, err
+			} else {
+				for k, v := range parsed {
+					for _, v2 := range v {
+						queryValues.Add(k, v2)
+					}
+				}
+			}
+
+		}
+
+		queryURL.RawQuery = queryValues.Encode()
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetMetricsRequest generates requests for GetMetrics
+func NewGetMetricsRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/metrics")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetOpenAPIRequest generates requests for GetOpenAPI
+func NewGetOpenAPIRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/openapi.json")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func (c *Client) applyEditors(ctx context.Context, req *http.Request, additionalEditors []RequestEditorFn) error {
+	for _, r := range c.RequestEditors {
+		if err := r(ctx, req); err != nil {
+			return err
+		}
+	}
+	for _, r := range additionalEditors {
+		if err := r(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClientWithResponses builds on ClientInterface to offer response payloads
+type ClientWithResponses struct {
+	ClientInterface
+}
+
+// NewClientWithResponses creates a new ClientWithResponses, which wraps
+// Client with return type handling
+func NewClientWithResponses(server string, opts ...ClientOption) (*ClientWithResponses, error) {
+	client, err := NewClient(server, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientWithResponses{client}, nil
+}
+
+// WithBaseURL overrides the baseURL.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) error {
+		newBaseURL, err := url.Parse(baseURL)
+		if err != nil {
+			return err
+		}
+		c.Server = newBaseURL.String()
+		return nil
+	}
+}
+
+// ClientWithResponsesInterface is the interface specification for the client with responses above.
+type ClientWithResponsesInterface interface {
+	// PurgeCacheWithResponse request
+	PurgeCacheWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*PurgeCacheResponse, error)
+
+	// GetDeliveriesWithResponse request
+	GetDeliveriesWithResponse(ctx context.Context, params *GetDeliveriesParams, reqEditors ...RequestEditorFn) (*GetDeliveriesResponse, error)
+
+	// GetDeliveriesHTMLWithResponse request
+	GetDeliveriesHTMLWithResponse(ctx context.Context, params *GetDeliveriesHTMLParams, reqEditors ...RequestEditorFn) (*GetDeliveriesHTMLResponse, error)
+
+	// StreamEventsWithResponse request
+	StreamEventsWithResponse(ctx context.Context, params *StreamEventsParams, reqEditors ...RequestEditorFn) (*StreamEventsResponse, error)
+
+	// PurgeCacheV1WithResponse request
+	PurgeCacheV1WithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*PurgeCacheV1Response, error)
+
+	// GetDeliveriesV1WithResponse request
+	GetDeliveriesV1WithResponse(ctx context.Context, params *GetDeliveriesV1Params, reqEditors ...RequestEditorFn) (*GetDeliveriesV1Response, error)
+
+	// GetMetricsWithResponse request
+	GetMetricsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetMetricsResponse, error)
+
+	// GetOpenAPIWithResponse request
+	GetOpenAPIWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetOpenAPIResponse, error)
+}
+
+type PurgeCacheResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *PurgeResponse
+	JSON500      *Error
+}
+
+// Status returns HTTPResponse.Status
+func (r PurgeCacheResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PurgeCacheResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetDeliveriesResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *DeliveriesResponse
+	JSON400      *Error
+	JSON404      *Error
+	JSON500      *Error
+}
+
+// Status returns HTTPResponse.Status
+func (r GetDeliveriesResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetDeliveriesResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetDeliveriesHTMLResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r GetDeliveriesHTMLResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetDeliveriesHTMLResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type StreamEventsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r StreamEventsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r StreamEventsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type PurgeCacheV1Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *PurgeEnvelope
+	JSON401      *ErrorEnvelope
+	JSON403      *ErrorEnvelope
+	JSON500      *ErrorEnvelope
+}
+
+// Status returns HTTPResponse.Status
+func (r PurgeCacheV1Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PurgeCacheV1Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetDeliveriesV1Response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *DeliveriesEnvelope
+	JSON400      *ErrorEnvelope
+	JSON401      *ErrorEnvelope
+	JSON403      *ErrorEnvelope
+	JSON404      *ErrorEnvelope
+	JSON500      *ErrorEnvelope
+	JSON502      *ErrorEnvelope
+}
+
+// Status returns HTTPResponse.Status
+func (r GetDeliveriesV1Response) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetDeliveriesV1Response) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetMetricsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+}
+
+// Status returns HTTPResponse.Status
+func (r GetMetricsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetMetricsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetOpenAPIResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *map[string]interface{}
+}
+
+// Status returns HTTPResponse.Status
+func (r GetOpenAPIResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetOpenAPIResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+// PurgeCacheWithResponse request returning *PurgeCacheResponse
+func (c *ClientWithResponses) PurgeCacheWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*PurgeCacheResponse, error) {
+	rsp, err := c.PurgeCache(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePurgeCacheResponse(rsp)
+}
+
+// GetDeliveriesWithResponse request returning *GetDeliveriesResponse
+func (c *ClientWithResponses) GetDeliveriesWithResponse(ctx context.Context, params *GetDeliveriesParams, reqEditors ...RequestEditorFn) (*GetDeliveriesResponse, error) {
+	rsp, err := c.GetDeliveries(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetDeliveriesResponse(rsp)
+}
+
+// GetDeliveriesHTMLWithResponse request returning *GetDeliveriesHTMLResponse
+func (c *ClientWithResponses) GetDeliveriesHTMLWithResponse(ctx context.Context, params *GetDeliveriesHTMLParams, reqEditors ...RequestEditorFn) (*GetDeliveriesHTMLResponse, error) {
+	rsp, err := c.GetDeliveriesHTML(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetDeliveriesHTMLResponse(rsp)
+}
+
+// StreamEventsWithResponse request returning *StreamEventsResponse
+func (c *ClientWithResponses) StreamEventsWithResponse(ctx context.Context, params *StreamEventsParams, reqEditors ...RequestEditorFn) (*StreamEventsResponse, error) {
+	rsp, err := c.StreamEvents(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStreamEventsResponse(rsp)
+}
+
+// PurgeCacheV1WithResponse request returning *PurgeCacheV1Response
+func (c *ClientWithResponses) PurgeCacheV1WithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*PurgeCacheV1Response, error) {
+	rsp, err := c.PurgeCacheV1(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePurgeCacheV1Response(rsp)
+}
+
+// GetDeliveriesV1WithResponse request returning *GetDeliveriesV1Response
+func (c *ClientWithResponses) GetDeliveriesV1WithResponse(ctx context.Context, params *GetDeliveriesV1Params, reqEditors ...RequestEditorFn) (*GetDeliveriesV1Response, error) {
+	rsp, err := c.GetDeliveriesV1(ctx, params, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetDeliveriesV1Response(rsp)
+}
+
+// GetMetricsWithResponse request returning *GetMetricsResponse
+func (c *ClientWithResponses) GetMetricsWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetMetricsResponse, error) {
+	rsp, err := c.GetMetrics(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetMetricsResponse(rsp)
+}
+
+// GetOpenAPIWithResponse request returning *GetOpenAPIResponse
+func (c *ClientWithResponses) GetOpenAPIWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetOpenAPIResponse, error) {
+	rsp, err := c.GetOpenAPI(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetOpenAPIResponse(rsp)
+}
+
+// ParsePurgeCacheResponse parses an HTTP response from a PurgeCacheWithResponse call
+func ParsePurgeCacheResponse(rsp *http.Response) (*PurgeCacheResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PurgeCacheResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest PurgeResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 500:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON500 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseGetDeliveriesResponse parses an HTTP response from a GetDeliveriesWithResponse call
+func ParseGetDeliveriesResponse(rsp *http.Response) (*GetDeliveriesResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetDeliveriesResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest DeliveriesResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 400:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON400 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 404:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON404 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 500:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON500 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseGetDeliveriesHTMLResponse parses an HTTP response from a GetDeliveriesHTMLWithResponse call
+func ParseGetDeliveriesHTMLResponse(rsp *http.Response) (*GetDeliveriesHTMLResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetDeliveriesHTMLResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParseStreamEventsResponse parses an HTTP response from a StreamEventsWithResponse call
+func ParseStreamEventsResponse(rsp *http.Response) (*StreamEventsResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &StreamEventsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParsePurgeCacheV1Response parses an HTTP response from a PurgeCacheV1WithResponse call
+func ParsePurgeCacheV1Response(rsp *http.Response) (*PurgeCacheV1Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PurgeCacheV1Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest PurgeEnvelope
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 401:
+		var dest ErrorEnvelope
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON401 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 403:
+		var dest ErrorEnvelope
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON403 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 500:
+		var dest ErrorEnvelope
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON500 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseGetDeliveriesV1Response parses an HTTP response from a GetDeliveriesV1WithResponse call
+func ParseGetDeliveriesV1Response(rsp *http.Response) (*GetDeliveriesV1Response, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetDeliveriesV1Response{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest DeliveriesEnvelope
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 400:
+		var dest ErrorEnvelope
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON400 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 401:
+		var dest ErrorEnvelope
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON401 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 403:
+		var dest ErrorEnvelope
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON403 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 404:
+		var dest ErrorEnvelope
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON404 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 500:
+		var dest ErrorEnvelope
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON500 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 502:
+		var dest ErrorEnvelope
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON502 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseGetMetricsResponse parses an HTTP response from a GetMetricsWithResponse call
+func ParseGetMetricsResponse(rsp *http.Response) (*GetMetricsResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetMetricsResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	return response, nil
+}
+
+// ParseGetOpenAPIResponse parses an HTTP response from a GetOpenAPIWithResponse call
+func ParseGetOpenAPIResponse(rsp *http.Response) (*GetOpenAPIResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetOpenAPIResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest map[string]interface{}
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
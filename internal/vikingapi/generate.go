@@ -0,0 +1,5 @@
+// Package vikingapi is a typed client for viking-api, generated from
+// cmd/viking-api/openapi.json.
+package vikingapi
+
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen@v2.4.1 --config=oapi-codegen.yaml ../../cmd/viking-api/openapi.json
@@ -0,0 +1,64 @@
+package vikingapi
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// generator has to match the go:generate directive in generate.go.
+const generator = "github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen@v2.4.1"
+
+// TestClientUpToDate regenerates the client from openapi.json and fails when
+// it differs from client.gen.go.
+func TestClientUpToDate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("regenerating the client is slow")
+	}
+
+	directive, err := os.ReadFile("generate.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(directive, []byte(generator)) {
+		t.Fatalf("generate.go doesn't use %s", generator)
+	}
+
+	// Without the module the generator can't run at all, e.g. offline.
+	if out, err := exec.Command("go", "run", generator, "-version").CombinedOutput(); err != nil {
+		t.Skipf("can't run oapi-codegen: %v\n%s", err, out)
+	}
+
+	config, err := os.ReadFile("oapi-codegen.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "client.gen.go")
+	config = []byte(strings.Replace(string(config), "output: client.gen.go", "output: "+output, 1))
+	configPath := filepath.Join(dir, "oapi-codegen.yaml")
+	if err := os.WriteFile(configPath, config, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("go", "run", generator, "--config="+configPath, "../../cmd/viking-api/openapi.json")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("oapi-codegen failed: %v\n%s", err, out)
+	}
+
+	want, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("client.gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("client.gen.go is out of date, run go generate ./internal/vikingapi")
+	}
+}
@@ -0,0 +1,13 @@
+// Package vocab holds the translation prompt shared by vocabmemo and layla.
+package vocab
+
+import "strings"
+
+// PromptFormat asks for the Polish translation of %INPUT% as the JSON that
+// vocabmemo stores in NocoDB.
+const PromptFormat = `Translate to Polish and return JSON. For sentences (has spaces): {"en":"input","pl":"translation","type":"sentence"}. For single words (no spaces): {"en":"word","pl":"translation","type":"noun|verb|etc","example":"Usage example","phonetic":"IPA","use_frequency":0-1,"difficulty":"easy|medium|hard"}. Input: %INPUT%`
+
+// Prompt returns PromptFormat for input.
+func Prompt(input string) string {
+	return strings.Replace(PromptFormat, "%INPUT%", input, -1)
+}